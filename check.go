@@ -4,25 +4,85 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/sourcegraph/checkup/check/certfile"
+	"github.com/sourcegraph/checkup/check/disk"
 	"github.com/sourcegraph/checkup/check/dns"
+	"github.com/sourcegraph/checkup/check/docker"
 	"github.com/sourcegraph/checkup/check/exec"
+	"github.com/sourcegraph/checkup/check/file"
+	"github.com/sourcegraph/checkup/check/grpc"
+	"github.com/sourcegraph/checkup/check/heartbeat"
 	"github.com/sourcegraph/checkup/check/http"
+	"github.com/sourcegraph/checkup/check/imap"
+	"github.com/sourcegraph/checkup/check/kubernetes"
+	"github.com/sourcegraph/checkup/check/load"
+	"github.com/sourcegraph/checkup/check/memory"
+	"github.com/sourcegraph/checkup/check/mysql"
+	"github.com/sourcegraph/checkup/check/ntp"
+	"github.com/sourcegraph/checkup/check/pop3"
+	"github.com/sourcegraph/checkup/check/postgres"
+	"github.com/sourcegraph/checkup/check/process"
+	"github.com/sourcegraph/checkup/check/redis"
+	"github.com/sourcegraph/checkup/check/scenario"
+	"github.com/sourcegraph/checkup/check/smtp"
+	"github.com/sourcegraph/checkup/check/ssh"
 	"github.com/sourcegraph/checkup/check/tcp"
 	"github.com/sourcegraph/checkup/check/tls"
+	"github.com/sourcegraph/checkup/check/websocket"
 )
 
 func checkerDecode(typeName string, config json.RawMessage) (Checker, error) {
 	switch typeName {
+	case certfile.Type:
+		return certfile.New(config)
+	case disk.Type:
+		return disk.New(config)
 	case dns.Type:
 		return dns.New(config)
+	case docker.Type:
+		return docker.New(config)
 	case exec.Type:
 		return exec.New(config)
+	case file.Type:
+		return file.New(config)
+	case grpc.Type:
+		return grpc.New(config)
+	case heartbeat.Type:
+		return heartbeat.New(config)
 	case http.Type:
 		return http.New(config)
+	case imap.Type:
+		return imap.New(config)
+	case kubernetes.Type:
+		return kubernetes.New(config)
+	case load.Type:
+		return load.New(config)
+	case memory.Type:
+		return memory.New(config)
+	case mysql.Type:
+		return mysql.New(config)
+	case ntp.Type:
+		return ntp.New(config)
+	case pop3.Type:
+		return pop3.New(config)
+	case postgres.Type:
+		return postgres.New(config)
+	case process.Type:
+		return process.New(config)
+	case redis.Type:
+		return redis.New(config)
+	case scenario.Type:
+		return scenario.New(config)
+	case smtp.Type:
+		return smtp.New(config)
+	case ssh.Type:
+		return ssh.New(config)
 	case tcp.Type:
 		return tcp.New(config)
 	case tls.Type:
 		return tls.New(config)
+	case websocket.Type:
+		return websocket.New(config)
 	default:
 		return nil, fmt.Errorf(errUnknownCheckerType, typeName)
 	}
@@ -0,0 +1,255 @@
+package certfile
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+
+	checkuptls "github.com/sourcegraph/checkup/check/tls"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "certfile"
+
+// Formats of certificate files for Checker.Format.
+const (
+	FormatPEM    = "pem"
+	FormatDER    = "der"
+	FormatPKCS12 = "pkcs12"
+)
+
+// Checker implements a Checker for certificates stored in
+// files, such as client certificates and CA bundles that are
+// never served on a listening port.
+//
+// Every certificate in the file is checked for expiry. If a
+// private key is available, either in a separate key file or
+// in a PKCS#12 file, it must match the first certificate.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// File is the path of the certificate file.
+	File string `json:"file"`
+
+	// Format is the format of File: FormatPEM, FormatDER
+	// or FormatPKCS12. If empty, it is detected from the
+	// contents and extension of the file.
+	Format string `json:"format,omitempty"`
+
+	// Password decrypts a PKCS#12 file.
+	Password string `json:"password,omitempty"`
+
+	// KeyFile is the path of a PEM-encoded private key
+	// that must match the first certificate in File.
+	KeyFile string `json:"key_file,omitempty"`
+
+	// CertExpiryThreshold is how close to expiration
+	// a certificate must be before declaring a degraded
+	// status. Default is tls.DefaultCertExpiryThreshold
+	// (14 days).
+	CertExpiryThreshold time.Duration `json:"cert_expiry_threshold,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.File == "" {
+		return types.Result{}, errors.New("certfile: file is required")
+	}
+	switch c.Format {
+	case "", FormatPEM, FormatDER, FormatPKCS12:
+	default:
+		return types.Result{}, fmt.Errorf("certfile: unknown format: %s", c.Format)
+	}
+	if c.CertExpiryThreshold == 0 {
+		c.CertExpiryThreshold = checkuptls.DefaultCertExpiryThreshold
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.File
+	result.Tags = c.Tags
+
+	start := time.Now()
+	certs, err := c.load()
+	result.Times = types.Attempts{{RTT: time.Since(start)}}
+	if err != nil {
+		result.Times[0].Error = err.Error()
+		result.Notice = err.Error()
+		result.Down = true
+		return result, nil
+	}
+
+	return c.conclude(certs, result), nil
+}
+
+// load reads the certificates in c.File and checks that the
+// private key, if any, matches the first of them.
+func (c Checker) load() ([]*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(c.File)
+	if err != nil {
+		return nil, err
+	}
+
+	var certPEM, keyPEM []byte
+	switch c.format(data) {
+	case FormatPEM:
+		certPEM = data
+	case FormatDER:
+		certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: data})
+	case FormatPKCS12:
+		certPEM, keyPEM, err = decodePKCS12(data, c.Password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var certs []*x509.Certificate
+	for rest := certPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+
+	if c.KeyFile != "" {
+		keyPEM, err = ioutil.ReadFile(c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if keyPEM != nil {
+		leaf := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certs[0].Raw})
+		if _, err := tls.X509KeyPair(leaf, keyPEM); err != nil {
+			return nil, fmt.Errorf("private key does not match certificate: %w", err)
+		}
+	}
+
+	return certs, nil
+}
+
+// format returns the format of data, the contents of c.File.
+func (c Checker) format(data []byte) string {
+	if c.Format != "" {
+		return c.Format
+	}
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		return FormatPEM
+	}
+	switch strings.ToLower(filepath.Ext(c.File)) {
+	case ".p12", ".pfx":
+		return FormatPKCS12
+	}
+	if c.Password != "" {
+		return FormatPKCS12
+	}
+	return FormatDER
+}
+
+// decodePKCS12 decrypts the PKCS#12 file data and returns its
+// certificates and private key as PEM. The certificate that
+// belongs to the key is returned first. Files without a key,
+// such as Java trust stores, yield only certificates.
+func decodePKCS12(data []byte, password string) (certPEM, keyPEM []byte, err error) {
+	key, leaf, chain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		certs, trustErr := pkcs12.DecodeTrustStore(data, password)
+		if trustErr != nil {
+			return nil, nil, fmt.Errorf("invalid PKCS#12 file: %w", err)
+		}
+		for _, cert := range certs {
+			certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+		}
+		return certPEM, nil, nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid PKCS#12 file: %w", err)
+	}
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	for _, cert := range append([]*x509.Certificate{leaf}, chain...) {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return certPEM, keyPEM, nil
+}
+
+// conclude checks certs for expiry and computes remaining
+// values needed to fill out the result.
+func (c Checker) conclude(certs []*x509.Certificate, result types.Result) types.Result {
+	// check expired certificates (down)
+	for _, cert := range certs {
+		if cert.NotAfter.Before(time.Now()) {
+			result.Notice = fmt.Sprintf("%s expired %s ago", describe(cert), time.Since(cert.NotAfter))
+			result.Times[0].Error = result.Notice
+			result.Down = true
+			return result
+		}
+	}
+
+	// check certificates expiring soon (degraded)
+	for _, cert := range certs {
+		if until := time.Until(cert.NotAfter); until < c.CertExpiryThreshold {
+			result.Notice = fmt.Sprintf("%s expiring soon (%s)", describe(cert), until)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// describe returns a description of cert for use in notices.
+func describe(cert *x509.Certificate) string {
+	return fmt.Sprintf("certificate %q", cert.Subject.CommonName)
+}
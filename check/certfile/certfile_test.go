@@ -0,0 +1,116 @@
+package certfile
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup-certfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	makeCert := func(name string, notAfter time.Time) ([]byte, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now().Add(-48 * time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+
+	leaf, err := ioutil.ReadFile("testdata/leaf.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := ioutil.ReadFile("testdata/root.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(leaf)
+	bundle := write("bundle.pem", append(leaf, root...))
+	der := write("leaf.der", block.Bytes)
+
+	soonDER, soonKey := makeCert("soon", time.Now().Add(7*24*time.Hour))
+	soon := write("soon.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: soonDER}))
+	otherKey := write("soon.key", soonKey)
+	expiredDER, _ := makeCert("expired", time.Now().Add(-24*time.Hour))
+	expired := write("expired.der", expiredDER)
+
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+	}{
+		{Checker{File: bundle}, "healthy", ""},
+		{Checker{File: bundle, KeyFile: "testdata/leaf.key"}, "healthy", ""},
+		{Checker{File: der}, "healthy", ""},
+		{Checker{File: der, Format: FormatDER, KeyFile: "testdata/leaf.key"}, "healthy", ""},
+		{Checker{File: "testdata/leaf.p12", Password: "checkup"}, "healthy", ""},
+		{Checker{File: "testdata/leaf-aes.p12", Password: "checkup"}, "healthy", ""},
+		{Checker{File: "testdata/leaf.p12", Password: "wrong"}, "down", "invalid PKCS#12 file"},
+		{Checker{File: bundle, KeyFile: otherKey}, "down", "private key does not match certificate"},
+		{Checker{File: soon}, "degraded", `certificate "soon" expiring soon`},
+		{Checker{File: soon, CertExpiryThreshold: 24 * time.Hour}, "healthy", ""},
+		{Checker{File: soon, KeyFile: otherKey, CertExpiryThreshold: 24 * time.Hour}, "healthy", ""},
+		{Checker{File: expired}, "down", `certificate "expired" expired`},
+		{Checker{File: filepath.Join(dir, "missing.pem")}, "down", "open"},
+		{Checker{File: "testdata/leaf.key"}, "down", "no certificates found"},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+		if got, want := len(result.Times), 1; got != want {
+			t.Errorf("Test %d: Expected %d attempt, got %d", i, want, got)
+		}
+	}
+
+	for _, tc := range []Checker{{}, {File: bundle, Format: "jks"}} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
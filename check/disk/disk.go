@@ -0,0 +1,112 @@
+package disk
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/hostcheck"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "disk"
+
+// Checker implements a Checker for the free space and inodes
+// of the local file systems, read with statfs. It only works
+// on Linux.
+//
+// The free space and inodes of each mount are reported as
+// the "free:{mount}" and "inodes_free:{mount}" metrics of the
+// result, in percent, along with "free_bytes:{mount}".
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Mounts are the mount points (or any path in the
+	// file systems) to check. Default is "/".
+	Mounts []string `json:"mounts,omitempty"`
+
+	// DegradedFreePercent and DownFreePercent are the
+	// percentages of free space below which a mount is
+	// degraded or down. Zero disables the threshold.
+	DegradedFreePercent float64 `json:"degraded_free_percent,omitempty"`
+	DownFreePercent     float64 `json:"down_free_percent,omitempty"`
+
+	// DegradedFreeInodesPercent and DownFreeInodesPercent
+	// are the percentages of free inodes below which a
+	// mount is degraded or down. Zero disables the
+	// threshold. File systems without a fixed number of
+	// inodes are not checked.
+	DegradedFreeInodesPercent float64 `json:"degraded_free_inodes_percent,omitempty"`
+	DownFreeInodesPercent     float64 `json:"down_free_inodes_percent,omitempty"`
+}
+
+// usage is the usage of a file system.
+type usage struct {
+	total, avail uint64
+	files, ffree uint64
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if len(c.Mounts) == 0 {
+		c.Mounts = []string{"/"}
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = strings.Join(c.Mounts, ",")
+	result.Tags = c.Tags
+
+	start := time.Now()
+	var eval hostcheck.Evaluation
+	for _, mount := range c.Mounts {
+		u, err := statfs(mount)
+		if err != nil {
+			eval.Down("%s: %v", mount, err)
+			continue
+		}
+		if u.total > 0 {
+			eval.Min("free:"+mount, percent(u.avail, u.total), "%", c.DegradedFreePercent, c.DownFreePercent)
+		}
+		eval.Metric("free_bytes:"+mount, float64(u.avail), "B")
+		if u.files > 0 {
+			eval.Min("inodes_free:"+mount, percent(u.ffree, u.files), "%", c.DegradedFreeInodesPercent, c.DownFreeInodesPercent)
+		}
+	}
+	result.Times = types.Attempts{{RTT: time.Since(start)}}
+
+	return eval.Conclude(result), nil
+}
+
+// percent returns part as a percentage of total.
+func percent(part, total uint64) float64 {
+	return float64(part) / float64(total) * 100
+}
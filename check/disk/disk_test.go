@@ -0,0 +1,38 @@
+// +build linux
+
+package disk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChecker(t *testing.T) {
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+	}{
+		{Checker{}, "healthy", ""},
+		{Checker{DegradedFreePercent: 100}, "degraded", "free:/ "},
+		{Checker{DegradedFreePercent: 100, DownFreePercent: 100}, "down", "free:/ "},
+		{Checker{Mounts: []string{"/", "/nonexistent"}}, "down", "/nonexistent: no such file or directory"},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+		if len(result.Metrics) == 0 {
+			t.Errorf("Test %d: Expected metrics, got none", i)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package disk
+
+import "syscall"
+
+// statfs returns the usage of the file system at path.
+func statfs(path string) (usage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return usage{}, err
+	}
+	bsize := uint64(st.Bsize)
+	return usage{
+		total: st.Blocks * bsize,
+		avail: st.Bavail * bsize,
+		files: st.Files,
+		ffree: st.Ffree,
+	}, nil
+}
@@ -0,0 +1,10 @@
+// +build !linux
+
+package disk
+
+import "errors"
+
+// statfs is only supported on Linux.
+func statfs(path string) (usage, error) {
+	return usage{}, errors.New("disk checks are only supported on Linux")
+}
@@ -20,6 +20,8 @@ type Checker struct {
 	Name string `json:"endpoint_name"`
 	// This is the name of the DNS server you are testing.
 	URL string `json:"endpoint_url"`
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
 	// This is the fqdn of the target server to query the DNS server for.
 	Host string `json:"hostname_fqdn,omitempty"`
 	// Timeout is the maximum time to wait for a
@@ -49,6 +51,16 @@ func (Checker) Type() string {
 	return Type
 }
 
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
 // Check performs checks using c according to its configuration.
 // An error is only returned if there is a configuration error.
 func (c Checker) Check() (types.Result, error) {
@@ -59,6 +71,7 @@ func (c Checker) Check() (types.Result, error) {
 	result := types.NewResult()
 	result.Title = c.Name
 	result.Endpoint = c.URL
+	result.Tags = c.Tags
 	result.Times = c.doChecks()
 
 	return c.conclude(result), nil
@@ -0,0 +1,290 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "docker"
+
+// DefaultHost is the address of the Docker Engine API used if
+// none is set.
+const DefaultHost = "unix:///var/run/docker.sock"
+
+// Checker implements a Checker for Docker containers, which
+// queries the Docker Engine API for the state of a container.
+//
+// A container is down if it isn't running or its health check
+// reports it unhealthy, and degraded while its health check is
+// starting or if it has restarted more than MaxRestarts times.
+// Its restart count and uptime are reported as the "restarts"
+// and "uptime" metrics of the result.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the address of the Docker Engine API: a
+	// unix:// socket, or a tcp://, http:// or https://
+	// address. Default is unix:///var/run/docker.sock.
+	URL string `json:"endpoint_url,omitempty"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Container is the name or ID of the container.
+	Container string `json:"container"`
+
+	// MaxRestarts is the number of times the container
+	// may have been restarted by its restart policy
+	// before it is considered degraded. Negative
+	// disables the check. Note that Docker counts
+	// restarts since the container was created.
+	MaxRestarts int `json:"max_restarts,omitempty"`
+
+	// Timeout is the maximum time to wait for the API.
+	// Default is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. If non-zero and a
+	// request takes longer than ThresholdRTT, the
+	// endpoint will be considered unhealthy. Note that
+	// this duration includes any in-between network
+	// latency.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many requests the client will
+	// make to the endpoint in a single check.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// container is the part of the reply of the Engine API to
+// GET /containers/{id}/json that is checked.
+type container struct {
+	State struct {
+		Status    string    `json:"Status"`
+		Running   bool      `json:"Running"`
+		ExitCode  int       `json:"ExitCode"`
+		StartedAt time.Time `json:"StartedAt"`
+		Health    *struct {
+			Status string `json:"Status"`
+			Log    []struct {
+				ExitCode int    `json:"ExitCode"`
+				Output   string `json:"Output"`
+			} `json:"Log"`
+		} `json:"Health"`
+	} `json:"State"`
+	RestartCount int `json:"RestartCount"`
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Container == "" {
+		return types.Result{}, errors.New("docker: missing container")
+	}
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.URL == "" {
+		c.URL = DefaultHost
+	}
+	client, base, err := newClient(c.URL, c.Timeout)
+	if err != nil {
+		return types.Result{}, err
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL + "/containers/" + c.Container
+	result.Tags = c.Tags
+	var last *container
+	result.Times, last = c.doChecks(client, base+"/containers/"+url.PathEscape(c.Container)+"/json")
+
+	return c.conclude(result, last), nil
+}
+
+// newClient returns a client for the Engine API at host, and
+// the base URL of its requests.
+func newClient(host string, timeout time.Duration) (*http.Client, string, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, "", err
+	}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &http.Client{Transport: transport, Timeout: timeout}, "http://docker", nil
+	case "tcp":
+		u.Scheme = "http"
+	case "http", "https":
+	default:
+		return nil, "", fmt.Errorf("docker: unsupported URL scheme: %s", host)
+	}
+	return &http.Client{Timeout: timeout}, strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// doChecks executes the checks and returns each attempt, along
+// with the last state of the container, if any. An attempt is
+// an error if the container isn't running or is unhealthy.
+func (c Checker) doChecks(client *http.Client, inspect string) (types.Attempts, *container) {
+	checks := make(types.Attempts, c.Attempts)
+	var last *container
+	for i := 0; i < c.Attempts; i++ {
+		start := time.Now()
+		ctr, err := c.inspect(client, inspect)
+		checks[i].RTT = time.Since(start)
+		if err != nil {
+			checks[i].Error = err.Error()
+			continue
+		}
+		last = ctr
+		if err := checkDown(ctr); err != nil {
+			checks[i].Error = err.Error()
+		}
+	}
+	return checks, last
+}
+
+// inspect fetches the state of the container from u.
+func (c Checker) inspect(client *http.Client, u string) (*container, error) {
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return nil, errors.New(apiErr.Message)
+		}
+		return nil, fmt.Errorf("response status %s", resp.Status)
+	}
+	var ctr container
+	if err := json.NewDecoder(resp.Body).Decode(&ctr); err != nil {
+		return nil, fmt.Errorf("decoding container: %v", err)
+	}
+	return &ctr, nil
+}
+
+// checkDown checks whether the container is down based on its
+// state. It returns a non-nil error if down.
+func checkDown(ctr *container) error {
+	state := ctr.State
+	if !state.Running {
+		return fmt.Errorf("container is %s (exit code %d)", state.Status, state.ExitCode)
+	}
+	if state.Health != nil && state.Health.Status == "unhealthy" {
+		if n := len(state.Health.Log); n > 0 {
+			log := state.Health.Log[n-1]
+			return fmt.Errorf("container is unhealthy: health check exited with %d: %s", log.ExitCode, strings.TrimSpace(log.Output))
+		}
+		return errors.New("container is unhealthy")
+	}
+	return nil
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It detects degraded (starting, restarting or high-latency)
+// containers and makes the conclusion about the result's
+// status.
+func (c Checker) conclude(result types.Result, last *container) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+	if last != nil {
+		result.Metrics = c.metrics(last)
+	}
+
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Notice = result.Times[i].Error
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check health and restarts (degraded)
+	if health := last.State.Health; health != nil && health.Status == "starting" {
+		result.Notice = "container health check is starting"
+		result.Degraded = true
+		return result
+	}
+	if c.MaxRestarts >= 0 && last.RestartCount > c.MaxRestarts {
+		result.Notice = fmt.Sprintf("container restarted %d times, more than %d", last.RestartCount, c.MaxRestarts)
+		result.Degraded = true
+		return result
+	}
+
+	// Check round trip time (degraded)
+	if c.ThresholdRTT > 0 {
+		stats := result.ComputeStats()
+		if stats.Median > c.ThresholdRTT {
+			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// metrics returns the restart count of ctr and, if it's
+// running, its uptime in seconds.
+func (c Checker) metrics(ctr *container) []types.Metric {
+	restarts := types.Metric{Label: "restarts", Value: float64(ctr.RestartCount), Unit: "c"}
+	if c.MaxRestarts >= 0 {
+		restarts.Warn = fmt.Sprint(c.MaxRestarts)
+	}
+	metrics := []types.Metric{restarts}
+	if ctr.State.Running && !ctr.State.StartedAt.IsZero() {
+		uptime := time.Since(ctr.State.StartedAt)
+		metrics = append(metrics, types.Metric{Label: "uptime", Value: uptime.Seconds(), Unit: "s"})
+	}
+	return metrics
+}
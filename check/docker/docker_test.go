@@ -0,0 +1,106 @@
+package docker
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// containers are the replies of the stub Engine API, by name.
+var containers = map[string]string{
+	"web":        `{"State": {"Status": "running", "Running": true, "StartedAt": "2020-01-01T00:00:00Z"}, "RestartCount": 0}`,
+	"healthy":    `{"State": {"Status": "running", "Running": true, "Health": {"Status": "healthy"}}, "RestartCount": 1}`,
+	"starting":   `{"State": {"Status": "running", "Running": true, "Health": {"Status": "starting"}}}`,
+	"unhealthy":  `{"State": {"Status": "running", "Running": true, "Health": {"Status": "unhealthy", "Log": [{"ExitCode": 1, "Output": "curl: (7) Failed to connect\n"}]}}}`,
+	"exited":     `{"State": {"Status": "exited", "Running": false, "ExitCode": 137}}`,
+	"restarting": `{"State": {"Status": "running", "Running": true}, "RestartCount": 5}`,
+}
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/containers/"), "/json")
+	ctr, ok := containers[name]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "No such container: ` + name + `"}`))
+		return
+	}
+	w.Write([]byte(ctr))
+}
+
+func TestChecker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup-docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "docker.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go http.Serve(ln, http.HandlerFunc(handler))
+
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+		metrics int
+	}{
+		{Checker{Container: "web"}, "healthy", "", 2},
+		{Checker{Container: "healthy", MaxRestarts: 1}, "healthy", "", 1},
+		{Checker{Container: "healthy"}, "degraded", "container restarted 1 times, more than 0", 1},
+		{Checker{Container: "starting"}, "degraded", "container health check is starting", 1},
+		{Checker{Container: "unhealthy"}, "down", "container is unhealthy: health check exited with 1: curl: (7) Failed to connect", 1},
+		{Checker{Container: "exited"}, "down", "container is exited (exit code 137)", 1},
+		{Checker{Container: "restarting", MaxRestarts: 3}, "degraded", "container restarted 5 times", 1},
+		{Checker{Container: "restarting", MaxRestarts: -1}, "healthy", "", 1},
+		{Checker{Container: "missing"}, "down", "No such container: missing", 0},
+		{Checker{Container: "web", ThresholdRTT: time.Nanosecond}, "degraded", "median round trip time exceeded threshold", 2},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = "unix://" + socket
+		tc.Attempts = 2
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+		if got, want := len(result.Metrics), test.metrics; got != want {
+			t.Errorf("Test %d: Expected %d metrics, got %v", i, want, result.Metrics)
+		}
+	}
+
+	// the API may also be served over TCP
+	srv := httptest.NewServer(http.HandlerFunc(handler))
+	defer srv.Close()
+	tc := Checker{Name: "Test", URL: "tcp://" + srv.Listener.Addr().String(), Container: "web"}
+	result, err := tc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	} else if !result.Healthy {
+		t.Errorf("Expected result.Healthy=true, got %s: %s", result.Status(), result.Notice)
+	}
+
+	for _, tc := range []Checker{
+		{},
+		{Container: "web", URL: "ftp://localhost"},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
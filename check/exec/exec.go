@@ -1,30 +1,74 @@
 package exec
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
 
+	"github.com/sourcegraph/checkup/internal/command"
 	"github.com/sourcegraph/checkup/types"
 )
 
 // Type should match the package name
 const Type = "exec"
 
+// ModeNagios is the Checker.Mode for Nagios plugins.
+const ModeNagios = "nagios"
+
 // Checker implements a Checker by running programs with os.Exec.
 type Checker struct {
 	// Name is the name of the endpoint.
 	Name string `json:"name"`
 
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
 	// Command is the main program entrypoint.
 	Command string `json:"command"`
 
 	// Arguments are individual program parameters.
 	Arguments []string `json:"arguments,omitempty"`
 
+	// Shell runs Command with "/bin/sh -c", so that it
+	// can be a shell pipeline. Arguments are then the
+	// positional parameters $1, $2 and so on.
+	Shell bool `json:"shell,omitempty"`
+
+	// Env are environment variables to set for the
+	// command, in addition to the inherited environment.
+	Env map[string]string `json:"env,omitempty"`
+
+	// ClearEnv runs the command with only the variables
+	// in Env, rather than inheriting the environment.
+	ClearEnv bool `json:"clear_env,omitempty"`
+
+	// Dir is the working directory of the command.
+	// Default is the current directory.
+	Dir string `json:"dir,omitempty"`
+
+	// Timeout is the maximum time the command may run
+	// before it and all of its child processes are
+	// killed. Default is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// MaxOutputSize is the maximum number of bytes of
+	// output to keep; the rest is discarded. Default
+	// is 1 MiB.
+	MaxOutputSize int `json:"max_output_size,omitempty"`
+
+	// Mode is how the outcome of the command is
+	// interpreted. By default, a zero exit code is
+	// healthy and any other is an error. In ModeNagios,
+	// the exit codes of Nagios plugins are healthy (0),
+	// degraded (1), down (2) and unknown (3); the first
+	// line of output is the notice, and its perfdata is
+	// stored as the metrics of the result.
+	Mode string `json:"mode,omitempty"`
+
 	// ThresholdRTT is the maximum round trip time to
 	// allow for a healthy endpoint. If non-zero and a
 	// request takes longer than ThresholdRTT, the
@@ -50,6 +94,18 @@ type Checker struct {
 	// slowing down checks if the response body is large.
 	MustNotContain string `json:"must_not_contain,omitempty"`
 
+	// StdoutMustContain and StdoutMustNotContain are
+	// like MustContain and MustNotContain, but only
+	// apply to the standard output of the command.
+	StdoutMustContain    string `json:"stdout_must_contain,omitempty"`
+	StdoutMustNotContain string `json:"stdout_must_not_contain,omitempty"`
+
+	// StderrMustContain and StderrMustNotContain are
+	// like MustContain and MustNotContain, but only
+	// apply to the standard error of the command.
+	StderrMustContain    string `json:"stderr_must_contain,omitempty"`
+	StderrMustNotContain string `json:"stderr_must_not_contain,omitempty"`
+
 	// Raise is a string that tells us if we should throw
 	// a hard error ("error" - the default), or if we should
 	// just mark something as degraded ("warn" or "warning").
@@ -78,48 +134,76 @@ func (Checker) Type() string {
 	return Type
 }
 
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
 // Check performs checks using c according to its configuration.
 // An error is only returned if there is a configuration error.
 func (c Checker) Check() (types.Result, error) {
 	if c.Attempts < 1 {
 		c.Attempts = 1
 	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.MaxOutputSize == 0 {
+		c.MaxOutputSize = 1 << 20
+	}
+	switch c.Mode {
+	case "", ModeNagios:
+	default:
+		return types.Result{}, fmt.Errorf("unknown exec mode: %s", c.Mode)
+	}
 
 	result := types.NewResult()
 	result.Title = c.Name
 	result.Endpoint = c.Command
-	result.Times = c.doChecks()
+	result.Tags = c.Tags
+	var plugins []pluginResult
+	result.Times, plugins = c.doChecks()
 
+	if c.Mode == ModeNagios {
+		result.ThresholdRTT = c.ThresholdRTT
+		return c.concludeNagios(result, plugins), nil
+	}
 	return c.conclude(result), nil
 }
 
-// doChecks executes command and returns each attempt.
-func (c Checker) doChecks() types.Attempts {
+// doChecks executes command and returns each attempt, along
+// with the outcome of each run in ModeNagios.
+func (c Checker) doChecks() (types.Attempts, []pluginResult) {
 	checks := make(types.Attempts, c.Attempts)
+	var plugins []pluginResult
+	if c.Mode == ModeNagios {
+		plugins = make([]pluginResult, c.Attempts)
+	}
 	for i := 0; i < c.Attempts; i++ {
 		start := time.Now()
-
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		// #nosec G204
-		command := exec.CommandContext(ctx, c.Command, c.Arguments...)
-		output, err := command.CombinedOutput()
-
+		out, err := c.run()
 		checks[i].RTT = time.Since(start)
 
-		if err != nil {
+		if c.Mode == ModeNagios {
+			plugins[i] = c.nagios(out, err)
+			if plugins[i].status == types.StatusDown {
+				checks[i].Error = plugins[i].notice
+			}
+		} else if err != nil {
 			stringify := func(s string) string {
 				if strings.TrimSpace(s) == "" {
 					return "empty"
 				}
 				return s
 			}
-			checks[i].Error = fmt.Sprintf("Error: %s\nOutput: %s\n", err.Error(), stringify(string(output)))
+			checks[i].Error = fmt.Sprintf("Error: %s\nOutput: %s\n", err.Error(), stringify(string(out.combined)))
 			continue
-		}
-
-		if err := c.checkDown(string(output)); err != nil {
+		} else if err := c.checkDown(out); err != nil {
 			checks[i].Error = err.Error()
 		}
 
@@ -127,7 +211,47 @@ func (c Checker) doChecks() types.Attempts {
 			time.Sleep(c.AttemptSpacing)
 		}
 	}
-	return checks
+	return checks, plugins
+}
+
+// commandOutput is the output of one run of the command.
+type commandOutput struct {
+	combined, stdout, stderr []byte
+}
+
+// run runs the command once and returns its output. If the
+// command doesn't finish within c.Timeout, its process group
+// is killed.
+func (c Checker) run() (commandOutput, error) {
+	var cmd *exec.Cmd
+	if c.Shell {
+		// #nosec G204
+		cmd = exec.Command("/bin/sh", append([]string{"-c", c.Command, "sh"}, c.Arguments...)...)
+	} else {
+		// #nosec G204
+		cmd = exec.Command(c.Command, c.Arguments...)
+	}
+	cmd.Dir = c.Dir
+	if c.ClearEnv || len(c.Env) > 0 {
+		env := []string{}
+		if !c.ClearEnv {
+			env = os.Environ()
+		}
+		for k, v := range c.Env {
+			env = append(env, k+"="+v)
+		}
+		cmd.Env = env
+	}
+
+	combined := &command.Buffer{Max: c.MaxOutputSize}
+	stdout := &command.Buffer{Max: c.MaxOutputSize}
+	stderr := &command.Buffer{Max: c.MaxOutputSize}
+	err := command.Run(cmd, io.MultiWriter(stdout, combined), io.MultiWriter(stderr, combined), c.Timeout)
+	return commandOutput{
+		combined: combined.Bytes(),
+		stdout:   stdout.Bytes(),
+		stderr:   stderr.Bytes(),
+	}, err
 }
 
 // conclude takes the data in result from the attempts and
@@ -166,19 +290,23 @@ func (c Checker) conclude(result types.Result) types.Result {
 	return result
 }
 
-// checkDown checks whether the endpoint is down based on resp and
+// checkDown checks whether the endpoint is down based on out and
 // the configuration of c. It returns a non-nil error if down.
 // Note that it does not check for degraded response.
-func (c Checker) checkDown(body string) error {
-	// Check response body
-	if c.MustContain == "" && c.MustNotContain == "" {
-		return nil
-	}
-	if c.MustContain != "" && !strings.Contains(body, c.MustContain) {
-		return fmt.Errorf("response does not contain '%s'", c.MustContain)
-	}
-	if c.MustNotContain != "" && strings.Contains(body, c.MustNotContain) {
-		return fmt.Errorf("response contains '%s'", c.MustNotContain)
+func (c Checker) checkDown(out commandOutput) error {
+	for _, check := range []struct {
+		name, body, mustContain, mustNotContain string
+	}{
+		{"response", string(out.combined), c.MustContain, c.MustNotContain},
+		{"stdout", string(out.stdout), c.StdoutMustContain, c.StdoutMustNotContain},
+		{"stderr", string(out.stderr), c.StderrMustContain, c.StderrMustNotContain},
+	} {
+		if check.mustContain != "" && !strings.Contains(check.body, check.mustContain) {
+			return fmt.Errorf("%s does not contain '%s'", check.name, check.mustContain)
+		}
+		if check.mustNotContain != "" && strings.Contains(check.body, check.mustNotContain) {
+			return fmt.Errorf("%s contains '%s'", check.name, check.mustNotContain)
+		}
 	}
 	return nil
 }
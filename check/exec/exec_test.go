@@ -1,7 +1,13 @@
 package exec
 
 import (
+	"fmt"
+	osexec "os/exec"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
 )
 
 func TestChecker(t *testing.T) {
@@ -35,3 +41,160 @@ func TestChecker(t *testing.T) {
 		assert(result.Down == false, "expected result.Down = false, got %v", result.Down)
 	}
 }
+
+func TestCheckerNagios(t *testing.T) {
+	command := "testdata/nagios.sh"
+
+	for i, test := range []struct {
+		code   string
+		status types.StatusText
+	}{
+		{"0", types.StatusHealthy},
+		{"1", types.StatusDegraded},
+		{"2", types.StatusDown},
+		{"3", types.StatusUnknown},
+		{"4", types.StatusUnknown},
+	} {
+		text := "CHECK " + test.code
+		hc := Checker{Name: "Nagios", Command: command, Arguments: []string{test.code, text}, Mode: ModeNagios}
+		result, err := hc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := result.Status(), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s", i, want, got)
+		}
+		if got, want := result.Notice, text; got != want {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, want, got)
+		}
+	}
+
+	hc := Checker{Name: "Nagios", Command: command, Arguments: []string{"0", "OK"}, Mode: ModeNagios}
+	result, err := hc.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := fmt.Sprint(result.Metrics), "[time=0.012s size=512B free space=75.5% load1=0.42]"; got != want {
+		t.Errorf("Expected metrics %s, got %s", want, got)
+	}
+	if m := result.Metrics[0]; m.Warn != "0.5" || m.Crit != "1" || m.Min == nil || *m.Min != 0 || m.Max != nil {
+		t.Errorf("Expected thresholds and bounds of time metric, got %+v", m)
+	}
+	if m := result.Metrics[1]; m.Warn != "" || m.Max == nil || *m.Max != 1024 {
+		t.Errorf("Expected bounds of size metric, got %+v", m)
+	}
+	if m := result.Metrics[2]; m.Warn != "20:" || m.Crit != "10:" {
+		t.Errorf("Expected thresholds of free space metric, got %+v", m)
+	}
+
+	// a failing MustContain overrides the exit code
+	hc.MustContain = "missing"
+	if result, _ := hc.Check(); !result.Down {
+		t.Errorf("Expected result.Down=true, got %s", result.Status())
+	}
+
+	// a command that can't be run is down
+	hc = Checker{Name: "Nagios", Command: "testdata/missing.sh", Mode: ModeNagios}
+	if result, _ := hc.Check(); !result.Down {
+		t.Errorf("Expected result.Down=true, got %s", result.Status())
+	}
+
+	hc = Checker{Name: "Nagios", Command: command, Mode: "icinga"}
+	if _, err := hc.Check(); err == nil {
+		t.Error("Expected an error for an unknown mode, didn't get one")
+	}
+}
+
+func TestParsePluginOutput(t *testing.T) {
+	for i, test := range []struct {
+		output  string
+		text    string
+		metrics string
+	}{
+		{"OK\n", "OK", "[]"},
+		{"", "", "[]"},
+		{"PING OK - rta=0.1ms|rta=0.1ms;;;0 pl=0%", "PING OK - rta=0.1ms", "[rta=0.1ms pl=0%]"},
+		{"OK | 'it''s'=1c x=U bad", "OK", "[it's=1c]"},
+		{"OK\nline 2\nline 3|a=1\nb=-2.5KB", "OK", "[a=1 b=-2.5KB]"},
+	} {
+		text, metrics := parsePluginOutput(test.output)
+		if text != test.text {
+			t.Errorf("Test %d: Expected text '%s', got '%s'", i, test.text, text)
+		}
+		if got := fmt.Sprint(metrics); got != test.metrics {
+			t.Errorf("Test %d: Expected metrics %s, got %s", i, test.metrics, got)
+		}
+	}
+}
+
+func TestCheckerOptions(t *testing.T) {
+	for i, test := range []struct {
+		checker Checker
+		healthy bool
+	}{
+		{Checker{Command: "echo $CHECKUP_TEST | tr a-z A-Z", Shell: true, Env: map[string]string{"CHECKUP_TEST": "ok"}, MustContain: "OK"}, true},
+		{Checker{Command: `echo "$1-$2"`, Arguments: []string{"a", "b"}, Shell: true, MustContain: "a-b"}, true},
+		{Checker{Command: "env", Env: map[string]string{"CHECKUP_TEST": "ok"}, MustContain: "PATH="}, true},
+		{Checker{Command: "env", Env: map[string]string{"CHECKUP_TEST": "ok"}, ClearEnv: true, MustContain: "PATH="}, false},
+		{Checker{Command: "env", Env: map[string]string{"CHECKUP_TEST": "ok"}, ClearEnv: true, MustContain: "CHECKUP_TEST=ok"}, true},
+		{Checker{Command: "pwd", Dir: "testdata", MustContain: "/testdata"}, true},
+		{Checker{Command: "echo out; echo err >&2", Shell: true, StdoutMustContain: "out", StderrMustContain: "err"}, true},
+		{Checker{Command: "echo out; echo err >&2", Shell: true, StdoutMustContain: "err"}, false},
+		{Checker{Command: "echo out; echo err >&2", Shell: true, StderrMustNotContain: "err"}, false},
+		{Checker{Command: "echo out; echo err >&2", Shell: true, MustContain: "err"}, true},
+		{Checker{Command: "yes | head -c 100000", Shell: true, MaxOutputSize: 10, StdoutMustNotContain: "y\ny\ny\ny\ny\ny"}, true},
+		{Checker{Command: "sleep 5", Shell: true, Timeout: 100 * time.Millisecond}, false},
+	} {
+		hc := test.checker
+		hc.Name = "Options"
+		result, err := hc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if result.Healthy != test.healthy {
+			t.Errorf("Test %d: Expected result.Healthy=%v, got %v: %v", i, test.healthy, result.Healthy, result.Times)
+		}
+	}
+}
+
+func TestCheckerTimeoutKillsProcessGroup(t *testing.T) {
+	// the background sleep holds stdout open, so the check
+	// only finishes early if it is killed too
+	hc := Checker{Name: "Timeout", Command: "sleep 5 & sleep 5; wait", Shell: true, Timeout: 100 * time.Millisecond}
+	start := time.Now()
+	result, err := hc.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down {
+		t.Errorf("Expected result.Down=true, got %s", result.Status())
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the check to be killed after its timeout, took %s", elapsed)
+	}
+	if !strings.Contains(result.Times[0].Error, "timed out after 100ms") {
+		t.Errorf("Expected a timeout error, got '%s'", result.Times[0].Error)
+	}
+}
+
+func TestCheckerTimeoutEscapedDescendant(t *testing.T) {
+	if _, err := osexec.LookPath("setsid"); err != nil {
+		t.Skip("setsid not found")
+	}
+	// the descendant leaves the process group, so it isn't
+	// killed and holds stdout open after the timeout
+	hc := Checker{Name: "Timeout", Command: "setsid sleep 5 & sleep 5", Shell: true, Timeout: 100 * time.Millisecond}
+	start := time.Now()
+	result, err := hc.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected the check to stop waiting for output, took %s", elapsed)
+	}
+	if !strings.Contains(result.Times[0].Error, "timed out after 100ms") {
+		t.Errorf("Expected a timeout error, got '%s'", result.Times[0].Error)
+	}
+}
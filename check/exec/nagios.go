@@ -0,0 +1,201 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Exit codes of Nagios plugins.
+const (
+	nagiosOK       = 0
+	nagiosWarning  = 1
+	nagiosCritical = 2
+	nagiosUnknown  = 3
+)
+
+// pluginResult is the outcome of one run of a Nagios plugin.
+type pluginResult struct {
+	status  types.StatusText
+	notice  string
+	metrics []types.Metric
+}
+
+// nagios interprets the output and error of one run of a
+// Nagios plugin according to the plugin API: the exit code is
+// the status, and the output is text with optional perfdata.
+func (c Checker) nagios(out commandOutput, err error) pluginResult {
+	code := nagiosOK
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return pluginResult{status: types.StatusDown, notice: err.Error()}
+		}
+		code = exitErr.ExitCode()
+	}
+
+	text, metrics := parsePluginOutput(string(out.stdout))
+	plugin := pluginResult{notice: text, metrics: metrics}
+	switch code {
+	case nagiosOK:
+		plugin.status = types.StatusHealthy
+	case nagiosWarning:
+		plugin.status = types.StatusDegraded
+	case nagiosCritical:
+		plugin.status = types.StatusDown
+	default:
+		plugin.status = types.StatusUnknown
+	}
+	if plugin.status != types.StatusDown {
+		if err := c.checkDown(out); err != nil {
+			plugin.status = types.StatusDown
+			plugin.notice = err.Error()
+		}
+	}
+	return plugin
+}
+
+// concludeNagios computes the status of result from the worst
+// of the plugin runs, whose text and perfdata are reported.
+func (c Checker) concludeNagios(result types.Result, plugins []pluginResult) types.Result {
+	worst := plugins[0]
+	for _, plugin := range plugins[1:] {
+		if severity(plugin.status) > severity(worst.status) {
+			worst = plugin
+		}
+	}
+	result.Notice = worst.notice
+	result.Metrics = worst.metrics
+
+	switch worst.status {
+	case types.StatusDown:
+		result.Down = true
+	case types.StatusDegraded:
+		result.Degraded = true
+	case types.StatusHealthy:
+		if c.ThresholdRTT > 0 && result.ComputeStats().Median > c.ThresholdRTT {
+			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
+			result.Degraded = true
+			break
+		}
+		result.Healthy = true
+	}
+	return result
+}
+
+// severity orders statuses from healthy to down, with unknown
+// between healthy and degraded.
+func severity(status types.StatusText) int {
+	switch status {
+	case types.StatusHealthy:
+		return 0
+	case types.StatusDegraded:
+		return 2
+	case types.StatusDown:
+		return 3
+	}
+	return 1
+}
+
+// parsePluginOutput splits the output of a Nagios plugin into
+// the first line of text and the metrics from its perfdata.
+// Perfdata follows a "|" on the first line, and on the lines
+// after the first "|" in the long output.
+func parsePluginOutput(output string) (string, []types.Metric) {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+
+	var perfdata []string
+	text := lines[0]
+	if i := strings.Index(text, "|"); i >= 0 {
+		perfdata = append(perfdata, text[i+1:])
+		text = text[:i]
+	}
+	for i, line := range lines[1:] {
+		if j := strings.Index(line, "|"); j >= 0 {
+			perfdata = append(perfdata, line[j+1:])
+			perfdata = append(perfdata, lines[i+2:]...)
+			break
+		}
+	}
+
+	var metrics []types.Metric
+	for _, field := range splitPerfData(strings.Join(perfdata, " ")) {
+		if metric, ok := parseMetric(field); ok {
+			metrics = append(metrics, metric)
+		}
+	}
+	return strings.TrimSpace(text), metrics
+}
+
+// splitPerfData splits perfdata into its space-separated
+// fields, keeping quoted labels, which may contain spaces,
+// intact.
+func splitPerfData(perfdata string) []string {
+	var fields []string
+	var field strings.Builder
+	quoted := false
+	for _, r := range perfdata {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			field.WriteRune(r)
+		case (r == ' ' || r == '\t' || r == '\r') && !quoted:
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+		default:
+			field.WriteRune(r)
+		}
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// parseMetric parses one perfdata field of the form
+// 'label'=value[UOM];[warn];[crit];[min];[max]. It returns
+// false if the field is invalid or its value is unknown.
+func parseMetric(field string) (types.Metric, bool) {
+	i := strings.LastIndex(field, "=")
+	if i <= 0 {
+		return types.Metric{}, false
+	}
+	label := field[:i]
+	if len(label) >= 2 && label[0] == '\'' && label[len(label)-1] == '\'' {
+		label = strings.Replace(label[1:len(label)-1], "''", "'", -1)
+	}
+
+	parts := strings.Split(field[i+1:], ";")
+	number := strings.TrimRightFunc(parts[0], func(r rune) bool {
+		return r == '%' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z'
+	})
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return types.Metric{}, false
+	}
+	metric := types.Metric{Label: label, Value: value, Unit: parts[0][len(number):]}
+
+	part := func(n int) string {
+		if n < len(parts) {
+			return parts[n]
+		}
+		return ""
+	}
+	bound := func(n int) *float64 {
+		if v, err := strconv.ParseFloat(part(n), 64); err == nil {
+			return &v
+		}
+		return nil
+	}
+	metric.Warn = part(1)
+	metric.Crit = part(2)
+	metric.Min = bound(3)
+	metric.Max = bound(4)
+	return metric, true
+}
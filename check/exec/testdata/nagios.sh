@@ -0,0 +1,8 @@
+#!/bin/bash
+# Usage: nagios.sh <exit code> <text>
+# Prints output in the format of a Nagios plugin.
+printf '%s | time=0.012s;0.5;1;0; size=512B;;;0;1024\n' "$2"
+printf 'long output\n'
+printf "more output | 'free space'=75.5%%;20:;10:\n"
+printf 'load1=0.42\n'
+exit $1
@@ -0,0 +1,251 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/jsonpath"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "file"
+
+// MaxContentSize is the size of the largest file whose content
+// can be checked. Larger files are down rather than checked in
+// part.
+const MaxContentSize = 10 << 20
+
+// Checker implements a Checker for files that pipelines write
+// or touch to signal that they are healthy, such as timestamp
+// files and export markers.
+//
+// The age and size of the file are reported as the "age" (in
+// seconds) and "size" (in bytes) metrics of the result.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Path is the path of the file, which must exist. If
+	// it is a glob pattern, such as /backups/*.tar.gz,
+	// the most recently modified match is checked.
+	Path string `json:"path"`
+
+	// MaxAge is the maximum time since the file was last
+	// modified. If zero, the age is not checked.
+	MaxAge time.Duration `json:"max_age,omitempty"`
+
+	// MinSize and MaxSize are the bounds of the size of
+	// the file, in bytes. Zero disables the bound.
+	MinSize int64 `json:"min_size,omitempty"`
+	MaxSize int64 `json:"max_size,omitempty"`
+
+	// MustContain is a string that the content must
+	// contain in order to be considered up.
+	MustContain string `json:"must_contain,omitempty"`
+
+	// MustNotContain is a string that the content must
+	// NOT contain in order to be considered up.
+	MustNotContain string `json:"must_not_contain,omitempty"`
+
+	// Regex is a regular expression that the content
+	// must match in order to be considered up.
+	Regex string `json:"regex,omitempty"`
+
+	// JSONPath is a path, such as $.status, that must
+	// exist in the content, which must be JSON.
+	JSONPath string `json:"json_path,omitempty"`
+
+	// JSONPathEquals is the value that JSONPath must
+	// have in order to be considered up, if set. Values
+	// other than strings are compared in their JSON
+	// form, such as true or 42.
+	JSONPathEquals string `json:"json_path_equals,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Path == "" {
+		return types.Result{}, errors.New("file: missing path")
+	}
+	if c.MaxSize > 0 && c.MaxSize < c.MinSize {
+		return types.Result{}, fmt.Errorf("file: max_size %d is less than min_size %d", c.MaxSize, c.MinSize)
+	}
+	var re *regexp.Regexp
+	if c.Regex != "" {
+		var err error
+		if re, err = regexp.Compile(c.Regex); err != nil {
+			return types.Result{}, err
+		}
+	}
+	var path *jsonpath.Path
+	if c.JSONPath != "" {
+		p, err := jsonpath.Parse(c.JSONPath)
+		if err != nil {
+			return types.Result{}, err
+		}
+		path = &p
+	} else if c.JSONPathEquals != "" {
+		return types.Result{}, errors.New("file: json_path_equals requires json_path")
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.Path
+	result.Tags = c.Tags
+
+	start := time.Now()
+	err := c.check(&result, re, path)
+	result.Times = types.Attempts{{RTT: time.Since(start)}}
+	if err != nil {
+		result.Times[0].Error = err.Error()
+		result.Notice = err.Error()
+		result.Down = true
+		return result, nil
+	}
+
+	result.Healthy = true
+	return result, nil
+}
+
+// check checks the file and stores its metrics in result. It
+// returns a non-nil error if down.
+func (c Checker) check(result *types.Result, re *regexp.Regexp, path *jsonpath.Path) error {
+	name, info, err := c.find()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", name)
+	}
+
+	age := time.Since(info.ModTime())
+	result.Metrics = []types.Metric{
+		{Label: "age", Value: age.Seconds(), Unit: "s"},
+		{Label: "size", Value: float64(info.Size()), Unit: "B"},
+	}
+	if c.MaxAge > 0 && age > c.MaxAge {
+		return fmt.Errorf("%s was modified %s ago, more than %s", name, age.Round(time.Second), c.MaxAge)
+	}
+	if c.MinSize > 0 && info.Size() < c.MinSize {
+		return fmt.Errorf("%s is %d bytes, less than %d", name, info.Size(), c.MinSize)
+	}
+	if c.MaxSize > 0 && info.Size() > c.MaxSize {
+		return fmt.Errorf("%s is %d bytes, more than %d", name, info.Size(), c.MaxSize)
+	}
+
+	if c.MustContain == "" && c.MustNotContain == "" && re == nil && path == nil {
+		return nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	content, err := ioutil.ReadAll(io.LimitReader(f, MaxContentSize+1))
+	if err != nil {
+		return err
+	}
+	if len(content) > MaxContentSize {
+		return fmt.Errorf("%s is larger than %d bytes, too large to check its content", name, MaxContentSize)
+	}
+	return c.checkContent(name, content, re, path)
+}
+
+// find returns the name and info of the file to check: c.Path,
+// or the most recently modified file that matches it.
+func (c Checker) find() (string, os.FileInfo, error) {
+	if !hasMeta(c.Path) {
+		info, err := os.Stat(c.Path)
+		return c.Path, info, err
+	}
+
+	matches, err := filepath.Glob(c.Path)
+	if err != nil {
+		return "", nil, err
+	}
+	var newest string
+	var newestInfo os.FileInfo
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || info.IsDir() {
+			// it may have been removed since
+			continue
+		}
+		if newestInfo == nil || info.ModTime().After(newestInfo.ModTime()) {
+			newest, newestInfo = match, info
+		}
+	}
+	if newestInfo == nil {
+		return "", nil, fmt.Errorf("no file matches %s", c.Path)
+	}
+	return newest, newestInfo, nil
+}
+
+// checkContent checks the content of the file name. It returns
+// a non-nil error if down.
+func (c Checker) checkContent(name string, content []byte, re *regexp.Regexp, path *jsonpath.Path) error {
+	if c.MustContain != "" && !strings.Contains(string(content), c.MustContain) {
+		return fmt.Errorf("%s does not contain '%s'", name, c.MustContain)
+	}
+	if c.MustNotContain != "" && strings.Contains(string(content), c.MustNotContain) {
+		return fmt.Errorf("%s contains '%s'", name, c.MustNotContain)
+	}
+	if re != nil && !re.Match(content) {
+		return fmt.Errorf("%s does not match /%s/", name, c.Regex)
+	}
+	if path != nil {
+		value, err := path.Lookup(content)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if c.JSONPathEquals != "" && value != c.JSONPathEquals {
+			return fmt.Errorf("%s: %s is '%s', expected '%s'", name, path, value, c.JSONPathEquals)
+		}
+	}
+	return nil
+}
+
+// hasMeta reports whether path contains any of the magic
+// characters recognized by filepath.Match.
+func hasMeta(path string) bool {
+	magicChars := `*?[`
+	if filepath.Separator != '\\' {
+		magicChars = `*?[\`
+	}
+	return strings.ContainsAny(path, magicChars)
+}
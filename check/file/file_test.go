@@ -0,0 +1,101 @@
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	for _, f := range []struct {
+		name    string
+		content string
+		age     time.Duration
+	}{
+		{"status.json", `{"status": "ok", "replication": {"lag": 2}}`, time.Minute},
+		{"backup-1.tar.gz", strings.Repeat("x", 100), 48 * time.Hour},
+		{"backup-2.tar.gz", strings.Repeat("x", 200), 2 * time.Hour},
+		{"backup-3.tar.gz", strings.Repeat("x", 10), 26 * time.Hour},
+	} {
+		name := filepath.Join(dir, f.name)
+		if err := ioutil.WriteFile(name, []byte(f.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, now.Add(-f.age), now.Add(-f.age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status := filepath.Join(dir, "status.json")
+	backups := filepath.Join(dir, "backup-*.tar.gz")
+	large := filepath.Join(dir, "large.log")
+	if err := ioutil.WriteFile(large, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(large, MaxContentSize+1); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+	}{
+		{Checker{Path: status}, "healthy", ""},
+		{Checker{Path: status, MaxAge: time.Hour}, "healthy", ""},
+		{Checker{Path: status, MaxAge: 30 * time.Second}, "down", status + " was modified 1m0s ago, more than 30s"},
+		{Checker{Path: status, MinSize: 100}, "down", status + " is 43 bytes, less than 100"},
+		{Checker{Path: status, MaxSize: 10}, "down", status + " is 43 bytes, more than 10"},
+		{Checker{Path: status, MustContain: `"ok"`, MustNotContain: "error"}, "healthy", ""},
+		{Checker{Path: status, MustContain: "error"}, "down", status + " does not contain 'error'"},
+		{Checker{Path: status, MustNotContain: "ok"}, "down", status + " contains 'ok'"},
+		{Checker{Path: status, Regex: `"lag": \d+`}, "healthy", ""},
+		{Checker{Path: status, Regex: `"lag": \d{2,}`}, "down", status + ` does not match /"lag": \d{2,}/`},
+		{Checker{Path: status, JSONPath: "$.replication.lag", JSONPathEquals: "2"}, "healthy", ""},
+		{Checker{Path: status, JSONPath: "$.status", JSONPathEquals: "failed"}, "down", status + ": $.status is 'ok', expected 'failed'"},
+		{Checker{Path: status, JSONPath: "$.missing"}, "down", status + ": $.missing not found"},
+		{Checker{Path: backups, MaxAge: 3 * time.Hour, MinSize: 150}, "healthy", ""},
+		{Checker{Path: backups, MaxAge: time.Hour}, "down", filepath.Join(dir, "backup-2.tar.gz") + " was modified 2h0m0s ago"},
+		{Checker{Path: filepath.Join(dir, "*.zip")}, "down", "no file matches"},
+		{Checker{Path: filepath.Join(dir, "missing")}, "down", "stat "},
+		{Checker{Path: dir}, "down", dir + " is a directory"},
+		{Checker{Path: large}, "healthy", ""},
+		{Checker{Path: large, MustNotContain: "error"}, "down", fmt.Sprintf("%s is larger than %d bytes", large, MaxContentSize)},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+	}
+
+	for _, tc := range []Checker{
+		{},
+		{Path: status, Regex: "("},
+		{Path: status, JSONPath: "$."},
+		{Path: status, JSONPathEquals: "ok"},
+		{Path: status, MinSize: 10, MaxSize: 5},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+var (
+	errReadingRootCert = errors.New("error reading root certificate")
+	errParsingRootCert = errors.New("error parsing root certificate")
+)
+
+// Type should match the package name
+const Type = "grpc"
+
+// Checker implements a Checker for gRPC services using the
+// gRPC health checking protocol (grpc.health.v1.Health/Check).
+//
+// A service that is SERVING is healthy, NOT_SERVING is down
+// and UNKNOWN is degraded.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the host:port of the gRPC server.
+	URL string `json:"endpoint_url"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Service is the name of the service to check. If
+	// empty, the overall health of the server is checked.
+	Service string `json:"service,omitempty"`
+
+	// Metadata are headers to send with each request,
+	// such as an authorization token.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// TLSEnabled controls whether to enable TLS or not.
+	// If set, TLS is enabled.
+	TLSEnabled bool `json:"tls,omitempty"`
+
+	// TLSSkipVerify controls whether to skip server TLS
+	// certificate validation or not.
+	TLSSkipVerify bool `json:"tls_skip_verify,omitempty"`
+
+	// TLSCAFile is the Certificate Authority used
+	// to validate the server TLS certificate.
+	TLSCAFile string `json:"tls_ca_file,omitempty"`
+
+	// TLSCertFile and TLSKeyFile are the PEM-encoded
+	// client certificate and key to present to servers
+	// that require mutual TLS.
+	TLSCertFile string `json:"tls_cert_file,omitempty"`
+	TLSKeyFile  string `json:"tls_key_file,omitempty"`
+
+	// TLSServerName is the name used for SNI and to
+	// verify the server TLS certificate. Default is the
+	// host from URL.
+	TLSServerName string `json:"tls_server_name,omitempty"`
+
+	// Timeout is the deadline for establishing the
+	// connection, and for each health check request.
+	// Default is 5 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. If non-zero and a
+	// request takes longer than ThresholdRTT, the
+	// endpoint will be considered unhealthy. Note that
+	// this duration includes any in-between network
+	// latency.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many requests the client will
+	// make to the endpoint in a single check.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+
+	creds := insecure.NewCredentials()
+	if c.TLSEnabled {
+		tlsConfig, err := c.tlsConfig()
+		if err != nil {
+			return types.Result{}, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+	result.Tags = c.Tags
+	var statuses []healthpb.HealthCheckResponse_ServingStatus
+	result.Times, statuses = c.doChecks(creds)
+
+	return c.conclude(result, statuses), nil
+}
+
+// tlsConfig returns the TLS config described by c.
+func (c Checker) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.TLSSkipVerify,
+		ServerName:         c.TLSServerName,
+	}
+	if c.TLSCAFile != "" {
+		rootPEM, err := ioutil.ReadFile(c.TLSCAFile)
+		if err != nil || rootPEM == nil {
+			return nil, errReadingRootCert
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(rootPEM) {
+			return nil, errParsingRootCert
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.TLSCertFile != "" || c.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// doChecks connects to the server, executes the health checks
+// and returns each attempt along with the serving status it
+// reported. The attempts share the connection, which is
+// established before they are timed; if it can't be, every
+// attempt fails with the reason.
+func (c Checker) doChecks(creds credentials.TransportCredentials) (types.Attempts, []healthpb.HealthCheckResponse_ServingStatus) {
+	checks := make(types.Attempts, c.Attempts)
+	statuses := make([]healthpb.HealthCheckResponse_ServingStatus, c.Attempts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	start := time.Now()
+	conn, err := grpc.DialContext(ctx, c.URL,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithBlock(),
+		grpc.WithReturnConnectionError(),
+		grpc.FailOnNonTempDialError(true),
+	)
+	rtt := time.Since(start)
+	cancel()
+	if err != nil {
+		for i := range checks {
+			checks[i].RTT = rtt
+			checks[i].Error = err.Error()
+		}
+		return checks, statuses
+	}
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	for i := 0; i < c.Attempts; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+		if len(c.Metadata) > 0 {
+			ctx = metadata.NewOutgoingContext(ctx, metadata.New(c.Metadata))
+		}
+
+		start := time.Now()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: c.Service})
+		checks[i].RTT = time.Since(start)
+		cancel()
+
+		if err != nil {
+			checks[i].Error = err.Error()
+			continue
+		}
+		statuses[i] = resp.Status
+		if resp.Status == healthpb.HealthCheckResponse_NOT_SERVING {
+			checks[i].Error = "service is NOT_SERVING"
+		}
+	}
+	return checks, statuses
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It detects degraded (high-latency or UNKNOWN) responses and
+// makes the conclusion about the result's status.
+func (c Checker) conclude(result types.Result, statuses []healthpb.HealthCheckResponse_ServingStatus) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Notice = result.Times[i].Error
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check serving status (degraded)
+	for _, status := range statuses {
+		if status != healthpb.HealthCheckResponse_SERVING {
+			result.Notice = fmt.Sprintf("service is %s", status)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	// Check round trip time (degraded)
+	if c.ThresholdRTT > 0 {
+		stats := result.ComputeStats()
+		if stats.Median > c.ThresholdRTT {
+			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	result.Healthy = true
+	return result
+}
@@ -0,0 +1,219 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestChecker(t *testing.T) {
+	srv, addr := serve(t, nil)
+	defer srv.Stop()
+
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+	}{
+		{Checker{}, "healthy", ""},
+		{Checker{Service: "serving"}, "healthy", ""},
+		{Checker{Service: "not-serving"}, "down", "service is NOT_SERVING"},
+		{Checker{Service: "unknown"}, "degraded", "service is UNKNOWN"},
+		{Checker{Service: "missing"}, "down", "rpc error: code = NotFound"},
+		{Checker{Service: "private"}, "down", "rpc error: code = Unauthenticated"},
+		{Checker{Service: "private", Metadata: map[string]string{"authorization": "Bearer secret"}}, "healthy", ""},
+		{Checker{Service: "serving", ThresholdRTT: time.Nanosecond}, "degraded", "median round trip time exceeded threshold"},
+		{Checker{Service: "serving", TLSEnabled: true, TLSSkipVerify: true, Timeout: 500 * time.Millisecond}, "down", ""},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = addr
+		tc.Attempts = 2
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+		if got, want := len(result.Times), tc.Attempts; got != want {
+			t.Errorf("Test %d: Expected %d attempts, got %d", i, want, got)
+		}
+	}
+
+	// a server that isn't running is down
+	srv.Stop()
+	tc := Checker{Name: "Test", URL: addr}
+	result, err := tc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	}
+	if !result.Down {
+		t.Errorf("Expected result.Down=true, got %s", result.Status())
+	}
+}
+
+func TestCheckerWithMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup-grpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, caKey := makeCert(t, dir, "ca", nil, nil)
+	makeCert(t, dir, "server", ca, caKey)
+	makeCert(t, dir, "client", ca, caKey)
+
+	serverPair, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	srv, addr := serve(t, &tls.Config{
+		Certificates: []tls.Certificate{serverPair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	})
+	defer srv.Stop()
+
+	for i, test := range []struct {
+		checker Checker
+		healthy bool
+	}{
+		{Checker{TLSCertFile: filepath.Join(dir, "client.pem"), TLSKeyFile: filepath.Join(dir, "client.key")}, true},
+		{Checker{TLSCertFile: filepath.Join(dir, "client.pem"), TLSKeyFile: filepath.Join(dir, "client.key"), TLSServerName: "example.com"}, false},
+		{Checker{}, false},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = addr
+		tc.Service = "serving"
+		tc.TLSEnabled = true
+		tc.TLSCAFile = filepath.Join(dir, "ca.pem")
+		tc.Timeout = time.Second
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if result.Healthy != test.healthy {
+			t.Errorf("Test %d: Expected result.Healthy=%v, got %v: %v", i, test.healthy, result.Healthy, result.Times)
+		}
+	}
+
+	for _, tc := range []Checker{
+		{URL: addr, TLSEnabled: true, TLSCAFile: filepath.Join(dir, "missing.pem")},
+		{URL: addr, TLSEnabled: true, TLSCertFile: filepath.Join(dir, "client.pem")},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
+
+// serve starts a gRPC server with a health service, using TLS
+// if config is not nil, and returns it with its address. The
+// "private" service requires an authorization header.
+func serve(t *testing.T, config *tls.Config) (*grpc.Server, string) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if r, ok := req.(*healthpb.HealthCheckRequest); ok && r.Service == "private" {
+			md, _ := metadata.FromIncomingContext(ctx)
+			if values := md.Get("authorization"); len(values) == 0 || values[0] != "Bearer secret" {
+				return nil, status.Error(codes.Unauthenticated, "missing token")
+			}
+		}
+		return handler(ctx, req)
+	}
+	opts := []grpc.ServerOption{grpc.UnaryInterceptor(auth)}
+	if config != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(config)))
+	}
+	srv := grpc.NewServer(opts...)
+
+	hs := health.NewServer()
+	hs.SetServingStatus("serving", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("private", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("not-serving", healthpb.HealthCheckResponse_NOT_SERVING)
+	hs.SetServingStatus("unknown", healthpb.HealthCheckResponse_UNKNOWN)
+	healthpb.RegisterHealthServer(srv, hs)
+
+	go srv.Serve(ln)
+	return srv, ln.Addr().String()
+}
+
+// makeCert creates a certificate for localhost signed by
+// parent, or a CA certificate if parent is nil, and writes it
+// and its key to name.pem and name.key in dir.
+func makeCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
@@ -0,0 +1,39 @@
+package heartbeat
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Handler returns a handler for the pings of jobs, which
+// records them in the store of their token in stores. It
+// serves /ping/{token} to report a successful run, and
+// /ping/{token}/start and /ping/{token}/fail to report that
+// a run started or failed. Unknown tokens are not found.
+func Handler(stores map[string]*Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/ping/")
+		token, event := path, EventSuccess
+		if i := strings.Index(path, "/"); i >= 0 {
+			token, event = path[:i], path[i+1:]
+		}
+		if event != EventSuccess && event != EventStart && event != EventFail {
+			http.NotFound(w, r)
+			return
+		}
+		store, ok := stores[token]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := store.Record(token, event, time.Now()); err != nil {
+			log.Printf("ERROR recording heartbeat: %v", err)
+			http.Error(w, "error recording heartbeat", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("OK\n"))
+	})
+}
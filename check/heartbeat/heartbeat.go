@@ -0,0 +1,195 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "heartbeat"
+
+// DefaultStateFile is where heartbeats are persisted by
+// default, relative to the working directory.
+const DefaultStateFile = "heartbeats.json"
+
+// Checker implements a Checker for jobs that report to
+// checkup, such as cron jobs and backups, rather than being
+// polled.
+//
+// Jobs report by requesting /ping/{token} from checkup serve
+// when a run succeeds, and optionally /ping/{token}/start when
+// it starts and /ping/{token}/fail when it fails. The endpoint
+// is down if no run ended within Period plus Grace, or if the
+// last run failed. The durations of the runs that ended within
+// that time are recorded as the attempts of the result.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// Token identifies the job in the URLs it pings. It
+	// should be hard to guess.
+	Token string `json:"token"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Period is how often the job is expected to run.
+	Period time.Duration `json:"period"`
+
+	// Grace is how late the job may be before it is
+	// considered down, to allow for variations in when
+	// it starts and how long it takes.
+	Grace time.Duration `json:"grace,omitempty"`
+
+	// StateFile is where heartbeats are persisted by
+	// checkup serve, and read by the checker. Default is
+	// DefaultStateFile.
+	StateFile string `json:"state_file,omitempty"`
+
+	// ThresholdRTT is the maximum median run duration to
+	// allow for a healthy job. If non-zero and runs take
+	// longer than ThresholdRTT, the job will be
+	// considered unhealthy. Only runs that reported their
+	// start have a duration.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// now returns the current time; overridden in tests.
+	now func() time.Time
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Store returns the store that c reads heartbeats from.
+func (c Checker) Store() *Store {
+	if c.StateFile == "" {
+		return NewStore(DefaultStateFile)
+	}
+	return NewStore(c.StateFile)
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Token == "" {
+		return types.Result{}, errors.New("heartbeat: missing token")
+	}
+	if strings.Contains(c.Token, "/") {
+		return types.Result{}, fmt.Errorf("heartbeat: token must not contain '/': %s", c.Token)
+	}
+	if c.Period <= 0 {
+		return types.Result{}, errors.New("heartbeat: period must be positive")
+	}
+	if c.now == nil {
+		c.now = time.Now
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = "/ping/" + mask(c.Token)
+	result.Tags = c.Tags
+
+	state, err := c.Store().Get(c.Token)
+	if err != nil {
+		result.Times = types.Attempts{{Error: err.Error()}}
+		result.Notice = err.Error()
+		result.Down = true
+		return result, nil
+	}
+	result.Times = c.attempts(state)
+
+	return c.conclude(result, state), nil
+}
+
+// attempts returns the runs in state that ended within the
+// period and grace as attempts.
+func (c Checker) attempts(state State) types.Attempts {
+	since := c.now().Add(-c.Period - c.Grace)
+	var attempts types.Attempts
+	for _, run := range state.Runs {
+		if run.End.Before(since) {
+			continue
+		}
+		attempt := types.Attempt{RTT: run.Duration()}
+		if run.Failed {
+			attempt.Error = "run failed"
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts
+}
+
+// conclude takes the data in result from the runs in state and
+// makes the conclusion about the result's status. Only the last
+// run decides whether the job is down; earlier failures are
+// kept as attempts but don't affect the status.
+func (c Checker) conclude(result types.Result, state State) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+
+	// Check last run (down)
+	last, ok := state.LastRun()
+	if !ok {
+		result.Notice = "no ping received yet"
+		result.Down = true
+		return result
+	}
+	if age := c.now().Sub(last.End); age > c.Period+c.Grace {
+		result.Notice = fmt.Sprintf("no ping for %s (period %s, grace %s)", age.Round(time.Second), c.Period, c.Grace)
+		if !state.Started.IsZero() {
+			result.Notice += fmt.Sprintf("; running since %s", state.Started.Format(time.RFC3339))
+		}
+		result.Down = true
+		return result
+	}
+	if last.Failed {
+		result.Notice = fmt.Sprintf("last run failed at %s", last.End.Format(time.RFC3339))
+		result.Down = true
+		return result
+	}
+
+	// Check run duration (degraded)
+	if c.ThresholdRTT > 0 {
+		stats := result.ComputeStats()
+		if stats.Median > c.ThresholdRTT {
+			result.Notice = fmt.Sprintf("median run duration exceeded threshold (%s)", c.ThresholdRTT)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// mask hides all but the last 4 characters of token, so that
+// results don't reveal it.
+func mask(token string) string {
+	if len(token) <= 4 {
+		return strings.Repeat("*", len(token))
+	}
+	return strings.Repeat("*", len(token)-4) + token[len(token)-4:]
+}
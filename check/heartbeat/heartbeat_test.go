@@ -0,0 +1,171 @@
+package heartbeat
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup-heartbeat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	record := func(store *Store, token, event string, ago time.Duration) {
+		if err := store.Record(token, event, now.Add(-ago)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store := NewStore(filepath.Join(dir, "heartbeats.json"))
+	record(store, "ok", EventStart, 70*time.Minute)
+	record(store, "ok", EventSuccess, 60*time.Minute)
+	record(store, "ok", EventStart, 12*time.Minute)
+	record(store, "ok", EventSuccess, 10*time.Minute)
+	record(store, "late", EventSuccess, 2*time.Hour)
+	record(store, "late", EventStart, 30*time.Minute)
+	record(store, "failed", EventSuccess, 30*time.Minute)
+	record(store, "failed", EventFail, 5*time.Minute)
+	record(store, "recovered", EventFail, 30*time.Minute)
+	record(store, "recovered", EventSuccess, 5*time.Minute)
+
+	for i, test := range []struct {
+		checker  Checker
+		status   string
+		notice   string
+		attempts int
+	}{
+		{Checker{Token: "ok"}, "healthy", "", 2},
+		{Checker{Token: "ok", ThresholdRTT: 5 * time.Minute}, "degraded", "median run duration exceeded threshold (5m0s)", 2},
+		{Checker{Token: "ok", Period: 30 * time.Minute}, "healthy", "", 1},
+		{Checker{Token: "late"}, "down", "no ping for 2h0m0s (period 1h0m0s, grace 5m0s); running since 2026-01-02T02:30:00Z", 0},
+		{Checker{Token: "failed"}, "down", "last run failed at 2026-01-02T02:55:00Z", 2},
+		{Checker{Token: "recovered"}, "healthy", "", 2},
+		{Checker{Token: "never"}, "down", "no ping received yet", 0},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		if tc.Period == 0 {
+			tc.Period = time.Hour
+		}
+		tc.Grace = 5 * time.Minute
+		tc.StateFile = store.File
+		tc.now = func() time.Time { return now }
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if got, want := result.Notice, test.notice; got != want {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, want, got)
+		}
+		if got, want := len(result.Times), test.attempts; got != want {
+			t.Errorf("Test %d: Expected %d attempts, got %d", i, want, got)
+		}
+		if strings.Contains(result.Endpoint, tc.Token) && len(tc.Token) > 4 {
+			t.Errorf("Test %d: Expected endpoint to mask the token, got %s", i, result.Endpoint)
+		}
+	}
+
+	for _, tc := range []Checker{
+		{Period: time.Hour},
+		{Token: "a/b", Period: time.Hour},
+		{Token: "ok"},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup-heartbeat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "heartbeats.json")
+	start := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	store := NewStore(file)
+	for i := 0; i < MaxRuns+5; i++ {
+		if err := store.Record("job", EventStart, start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Record("job", EventSuccess, start.Add(time.Duration(i)*time.Hour+time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// state survives a restart
+	state, err := NewStore(file).Get("job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(state.Runs), MaxRuns; got != want {
+		t.Fatalf("Expected %d runs, got %d", want, got)
+	}
+	last, _ := state.LastRun()
+	if got, want := last.Duration(), time.Minute; got != want {
+		t.Errorf("Expected last run to take %s, got %s", want, got)
+	}
+	if !state.Started.IsZero() {
+		t.Errorf("Expected no run in progress, got one started at %s", state.Started)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup-heartbeat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewStore(filepath.Join(dir, "heartbeats.json"))
+	srv := httptest.NewServer(Handler(map[string]*Store{"backup": store}))
+	defer srv.Close()
+
+	for i, test := range []struct {
+		path   string
+		status int
+	}{
+		{"/ping/backup/start", http.StatusOK},
+		{"/ping/backup", http.StatusOK},
+		{"/ping/backup/fail", http.StatusOK},
+		{"/ping/backup/pause", http.StatusNotFound},
+		{"/ping/unknown", http.StatusNotFound},
+	} {
+		resp, err := http.Post(srv.URL+test.path, "text/plain", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got, want := resp.StatusCode, test.status; got != want {
+			t.Errorf("Test %d: Expected status %d for %s, got %d", i, want, test.path, got)
+		}
+	}
+
+	state, err := store.Get("backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(state.Runs), 2; got != want {
+		t.Fatalf("Expected %d runs, got %d", want, got)
+	}
+	if state.Runs[0].Start.IsZero() || state.Runs[0].Failed {
+		t.Errorf("Expected first run to have started and succeeded, got %+v", state.Runs[0])
+	}
+	if !state.Runs[1].Start.IsZero() || !state.Runs[1].Failed {
+		t.Errorf("Expected second run to have failed without a start, got %+v", state.Runs[1])
+	}
+}
@@ -0,0 +1,152 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MaxRuns is how many of the most recent runs of a job are
+// kept in its State.
+const MaxRuns = 20
+
+// The events that a job reports with pings.
+const (
+	// EventSuccess reports that a run succeeded.
+	EventSuccess = ""
+
+	// EventStart reports that a run started.
+	EventStart = "start"
+
+	// EventFail reports that a run failed.
+	EventFail = "fail"
+)
+
+// Run is one run of a job, which ends with a success or
+// failure ping. Start is only set if the job reported it.
+type Run struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Failed bool      `json:"failed,omitempty"`
+}
+
+// Duration returns how long the run took, or 0 if its start
+// is unknown.
+func (r Run) Duration() time.Duration {
+	if r.Start.IsZero() {
+		return 0
+	}
+	return r.End.Sub(r.Start)
+}
+
+// State is what is known about a job from its pings.
+type State struct {
+	// Started is when the current run started, if
+	// the job is running and reported its start.
+	Started time.Time `json:"started"`
+
+	// Runs are the most recent runs, oldest first.
+	Runs []Run `json:"runs,omitempty"`
+}
+
+// LastRun returns the most recent run, if any.
+func (s State) LastRun() (Run, bool) {
+	if len(s.Runs) == 0 {
+		return Run{}, false
+	}
+	return s.Runs[len(s.Runs)-1], true
+}
+
+// Store persists the State of jobs, by token, in a JSON file,
+// so that it survives restarts and can be read by checkers in
+// other processes.
+type Store struct {
+	// File is the path of the JSON file.
+	File string
+
+	mu sync.Mutex
+}
+
+// NewStore returns a Store that persists state in file.
+func NewStore(file string) *Store {
+	return &Store{File: file}
+}
+
+// Get returns the state of the job with token. A job that
+// never reported anything has a zero State.
+func (s *Store) Get(token string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states, err := s.load()
+	if err != nil {
+		return State{}, err
+	}
+	return states[token], nil
+}
+
+// Record records event, reported by the job with token at
+// time now.
+func (s *Store) Record(token, event string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	state := states[token]
+	if event == EventStart {
+		state.Started = now
+	} else {
+		state.Runs = append(state.Runs, Run{
+			Start:  state.Started,
+			End:    now,
+			Failed: event == EventFail,
+		})
+		if len(state.Runs) > MaxRuns {
+			state.Runs = state.Runs[len(state.Runs)-MaxRuns:]
+		}
+		state.Started = time.Time{}
+	}
+	states[token] = state
+
+	return s.save(states)
+}
+
+// load reads the states from s.File, which may not exist yet.
+func (s *Store) load() (map[string]State, error) {
+	states := map[string]State{}
+	b, err := ioutil.ReadFile(s.File)
+	if os.IsNotExist(err) {
+		return states, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &states)
+	return states, err
+}
+
+// save writes states to s.File, replacing it atomically so
+// that readers never see a partial file.
+func (s *Store) save(states map[string]State) error {
+	b, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.File), filepath.Base(s.File)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.File)
+}
@@ -0,0 +1,268 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenLifetime is how long an OAuth2 access token is
+// reused if the token endpoint doesn't say when it expires.
+const DefaultTokenLifetime = 5 * time.Minute
+
+// BasicAuth holds the credentials for HTTP basic
+// authentication.
+type BasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+}
+
+// OAuth2 configures the OAuth2 client credentials grant, which
+// exchanges the credentials of a client for an access token
+// that is sent as a bearer token. Tokens are cached, and
+// reused across attempts and checks until shortly before
+// they expire or an endpoint rejects them.
+type OAuth2 struct {
+	// TokenURL is the URL of the token endpoint.
+	TokenURL string `json:"token_url"`
+
+	// ClientID and ClientSecret are the credentials of
+	// the client, which are sent with basic auth.
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+
+	// ClientSecretEnv is the name of an environment
+	// variable to read ClientSecret from instead.
+	ClientSecretEnv string `json:"client_secret_env,omitempty"`
+
+	// Scopes are the scopes to request, if any.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Params are additional parameters of the token
+	// request, such as an audience.
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// token is a cached access token.
+type token struct {
+	value   string
+	expires time.Time
+}
+
+// tokenEntry is the cache entry of one configuration.
+type tokenEntry struct {
+	// fetch is held while requesting a token, so that
+	// concurrent checks share one request.
+	fetch sync.Mutex
+
+	// token is guarded by tokens.
+	token token
+}
+
+// tokens caches access tokens by the configuration they were
+// requested with. It is only locked briefly, never across a
+// token request.
+var tokens = struct {
+	sync.Mutex
+	m map[string]*tokenEntry
+}{m: make(map[string]*tokenEntry)}
+
+// authorize returns a function that sets the Authorization
+// header of requests according to the configuration of c. It
+// returns nil if no authentication is configured.
+func (c Checker) authorize() (func(req *http.Request) error, error) {
+	var schemes []string
+	if c.BasicAuth != nil {
+		schemes = append(schemes, "basic_auth")
+	}
+	if c.BearerTokenFile != "" {
+		schemes = append(schemes, "bearer_token_file")
+	}
+	if c.BearerTokenEnv != "" {
+		schemes = append(schemes, "bearer_token_env")
+	}
+	if c.OAuth2 != nil {
+		schemes = append(schemes, "oauth2")
+	}
+	if len(schemes) > 1 {
+		return nil, fmt.Errorf("http: only one of %s may be set", strings.Join(schemes, ", "))
+	}
+
+	switch {
+	case c.BasicAuth != nil:
+		auth := *c.BasicAuth
+		return func(req *http.Request) error {
+			req.SetBasicAuth(auth.Username, auth.Password)
+			return nil
+		}, nil
+	case c.BearerTokenFile != "" || c.BearerTokenEnv != "":
+		bearer, err := c.bearerToken()
+		if err != nil {
+			return nil, err
+		}
+		return func(req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+			return nil
+		}, nil
+	case c.OAuth2 != nil:
+		config := *c.OAuth2
+		if config.TokenURL == "" || config.ClientID == "" {
+			return nil, errors.New("http: oauth2 requires token_url and client_id")
+		}
+		if config.ClientSecretEnv != "" {
+			config.ClientSecret = os.Getenv(config.ClientSecretEnv)
+			if config.ClientSecret == "" {
+				return nil, fmt.Errorf("http: environment variable %s is empty", config.ClientSecretEnv)
+			}
+		}
+		client := c.Client
+		return func(req *http.Request) error {
+			bearer, err := config.token(client)
+			if err != nil {
+				return fmt.Errorf("oauth2: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+bearer)
+			return nil
+		}, nil
+	}
+	return nil, nil
+}
+
+// bearerToken reads the bearer token from the file or the
+// environment variable configured in c.
+func (c Checker) bearerToken() (string, error) {
+	if c.BearerTokenFile != "" {
+		b, err := ioutil.ReadFile(c.BearerTokenFile)
+		if err != nil {
+			return "", fmt.Errorf("http: reading bearer token: %v", err)
+		}
+		if bearer := strings.TrimSpace(string(b)); bearer != "" {
+			return bearer, nil
+		}
+		return "", fmt.Errorf("http: bearer token file %s is empty", c.BearerTokenFile)
+	}
+	if bearer := strings.TrimSpace(os.Getenv(c.BearerTokenEnv)); bearer != "" {
+		return bearer, nil
+	}
+	return "", fmt.Errorf("http: environment variable %s is empty", c.BearerTokenEnv)
+}
+
+// token returns a cached access token for o, or requests a
+// new one with client if there is none or it is about to
+// expire.
+func (o OAuth2) token(client *http.Client) (string, error) {
+	key, err := json.Marshal(o)
+	if err != nil {
+		return "", err
+	}
+	cached := func(entry *tokenEntry) (string, bool) {
+		tokens.Lock()
+		defer tokens.Unlock()
+		t := entry.token
+		return t.value, t.value != "" && time.Now().Before(t.expires)
+	}
+
+	tokens.Lock()
+	entry, ok := tokens.m[string(key)]
+	if !ok {
+		entry = new(tokenEntry)
+		tokens.m[string(key)] = entry
+	}
+	tokens.Unlock()
+	if value, ok := cached(entry); ok {
+		return value, nil
+	}
+
+	entry.fetch.Lock()
+	defer entry.fetch.Unlock()
+	// another check may have requested one meanwhile
+	if value, ok := cached(entry); ok {
+		return value, nil
+	}
+	t, err := o.requestToken(client)
+	if err != nil {
+		return "", err
+	}
+	tokens.Lock()
+	entry.token = t
+	tokens.Unlock()
+	return t.value, nil
+}
+
+// evictToken removes the access token value from the cache,
+// such as after an endpoint rejects it because it has been
+// revoked.
+func evictToken(value string) {
+	tokens.Lock()
+	defer tokens.Unlock()
+	for _, entry := range tokens.m {
+		if entry.token.value == value {
+			entry.token = token{}
+		}
+	}
+}
+
+// requestToken requests a new access token for o with client.
+func (o OAuth2) requestToken(client *http.Client) (token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+	for key, value := range o.Params {
+		form.Set(key, value)
+	}
+	req, err := http.NewRequest(http.MethodPost, o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return token{}, err
+	}
+	defer resp.Body.Close()
+
+	var reply struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return token{}, fmt.Errorf("reading token response: %v", err)
+	}
+	if err := json.Unmarshal(body, &reply); err != nil && resp.StatusCode == http.StatusOK {
+		return token{}, fmt.Errorf("decoding token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || reply.Error != "" {
+		if reply.Error != "" {
+			return token{}, fmt.Errorf("token request failed with status %s: %s", resp.Status, strings.TrimSpace(reply.Error+" "+reply.ErrorDescription))
+		}
+		return token{}, fmt.Errorf("token request failed with status %s", resp.Status)
+	}
+	if reply.AccessToken == "" {
+		return token{}, errors.New("no access_token in token response")
+	}
+
+	lifetime := DefaultTokenLifetime
+	if reply.ExpiresIn > 0 {
+		lifetime = time.Duration(reply.ExpiresIn) * time.Second
+		// renew tokens a little early, so that they don't
+		// expire in flight
+		lifetime -= lifetime / 10
+	}
+	return token{value: reply.AccessToken, expires: start.Add(lifetime)}, nil
+}
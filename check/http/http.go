@@ -1,6 +1,8 @@
 package http
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -23,6 +25,9 @@ type Checker struct {
 	// URL is the URL of the endpoint.
 	URL string `json:"endpoint_url"`
 
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
 	// UpStatus is the HTTP status code expected by
 	// a healthy endpoint. Default is http.StatusOK.
 	UpStatus int `json:"up_status,omitempty"`
@@ -64,12 +69,50 @@ type Checker struct {
 
 	// Client is the http.Client with which to make
 	// requests. If not set, DefaultHTTPClient is
-	// used.
+	// used, with the TLS options below applied. They
+	// are ignored if Client is set.
 	Client *http.Client `json:"-"`
 
 	// Headers contains headers to added to the request
 	// that is sent for the check
 	Headers http.Header `json:"headers,omitempty"`
+
+	// BasicAuth sets credentials for HTTP basic
+	// authentication.
+	BasicAuth *BasicAuth `json:"basic_auth,omitempty"`
+
+	// BearerTokenFile and BearerTokenEnv are a file and
+	// an environment variable to read a bearer token
+	// from. The token is read on every check, so it may
+	// be rotated without changing the configuration.
+	BearerTokenFile string `json:"bearer_token_file,omitempty"`
+	BearerTokenEnv  string `json:"bearer_token_env,omitempty"`
+
+	// OAuth2 configures the OAuth2 client credentials
+	// grant, to authenticate with an access token.
+	// Only one of BasicAuth, BearerTokenFile,
+	// BearerTokenEnv and OAuth2 may be set.
+	OAuth2 *OAuth2 `json:"oauth2,omitempty"`
+
+	// TLSCAFile is a bundle of PEM-encoded Certificate
+	// Authorities used to validate the server TLS
+	// certificate, instead of the system roots.
+	TLSCAFile string `json:"tls_ca_file,omitempty"`
+
+	// TLSCertFile and TLSKeyFile are the PEM-encoded
+	// client certificate and key to present to servers
+	// that require mutual TLS.
+	TLSCertFile string `json:"tls_cert_file,omitempty"`
+	TLSKeyFile  string `json:"tls_key_file,omitempty"`
+
+	// TLSSkipVerify controls whether to skip server TLS
+	// certificate validation or not.
+	TLSSkipVerify bool `json:"tls_skip_verify,omitempty"`
+
+	// ServerName is the name used for SNI and to verify
+	// the server TLS certificate. Default is the host
+	// from URL.
+	ServerName string `json:"server_name,omitempty"`
 }
 
 // New creates a new Checker instance based on json config
@@ -84,6 +127,16 @@ func (Checker) Type() string {
 	return Type
 }
 
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
 // Check performs checks using c according to its configuration.
 // An error is only returned if there is a configuration error.
 func (c Checker) Check() (types.Result, error) {
@@ -91,12 +144,21 @@ func (c Checker) Check() (types.Result, error) {
 		c.Attempts = 1
 	}
 	if c.Client == nil {
-		c.Client = DefaultHTTPClient
+		client, err := c.tlsClient()
+		if err != nil {
+			return types.Result{}, err
+		}
+		c.Client = client
+	}
+	authorize, err := c.authorize()
+	if err != nil {
+		return types.Result{}, err
 	}
 
 	result := types.NewResult()
 	result.Title = c.Name
 	result.Endpoint = c.URL
+	result.Tags = c.Tags
 
 	req, err := http.NewRequest("GET", c.URL, nil)
 	if err != nil {
@@ -113,15 +175,26 @@ func (c Checker) Check() (types.Result, error) {
 		}
 	}
 
-	result.Times = c.doChecks(req)
+	result.Times = c.doChecks(req, authorize)
 
 	return c.conclude(result), nil
 }
 
 // doChecks executes req using c.Client and returns each attempt.
-func (c Checker) doChecks(req *http.Request) types.Attempts {
+// If authorize is not nil, it authenticates req before each
+// attempt; the time it takes, such as to request an OAuth2
+// token, only counts toward the attempt if it fails.
+func (c Checker) doChecks(req *http.Request, authorize func(*http.Request) error) types.Attempts {
 	checks := make(types.Attempts, c.Attempts)
 	for i := 0; i < c.Attempts; i++ {
+		if authorize != nil {
+			start := time.Now()
+			if err := authorize(req); err != nil {
+				checks[i].RTT = time.Since(start)
+				checks[i].Error = err.Error()
+				continue
+			}
+		}
 		start := time.Now()
 		resp, err := c.Client.Do(req)
 		checks[i].RTT = time.Since(start)
@@ -129,6 +202,11 @@ func (c Checker) doChecks(req *http.Request) types.Attempts {
 			checks[i].Error = err.Error()
 			continue
 		}
+		if resp.StatusCode == http.StatusUnauthorized && c.OAuth2 != nil {
+			// the token may have been revoked, so the next
+			// attempt requests a new one
+			evictToken(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+		}
 		err = c.checkDown(resp)
 		if err != nil {
 			checks[i].Error = err.Error()
@@ -214,6 +292,42 @@ func (c Checker) checkDown(resp *http.Response) error {
 	return nil
 }
 
+// tlsClient returns DefaultHTTPClient, or a copy of it with
+// the TLS options of c if any are set.
+func (c Checker) tlsClient() (*http.Client, error) {
+	if c.TLSCAFile == "" && c.TLSCertFile == "" && c.TLSKeyFile == "" && !c.TLSSkipVerify && c.ServerName == "" {
+		return DefaultHTTPClient, nil
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.TLSSkipVerify,
+		ServerName:         c.ServerName,
+	}
+	if c.TLSCAFile != "" {
+		rootPEM, err := ioutil.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("http: reading CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(rootPEM) {
+			return nil, fmt.Errorf("http: no certificates in %s", c.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.TLSCertFile != "" || c.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("http: loading client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	client := *DefaultHTTPClient
+	transport := DefaultHTTPClient.Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client.Transport = transport
+	return &client, nil
+}
+
 // DefaultHTTPClient is used when no other http.Client
 // is specified on a Checker.
 var DefaultHTTPClient = &http.Client{
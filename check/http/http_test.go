@@ -1,9 +1,23 @@
 package http
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -14,7 +28,7 @@ func TestChecker(t *testing.T) {
 		fmt.Fprintln(w, "I'm up", "@"+r.Host)
 	}))
 	endpt := "http://" + srv.Listener.Addr().String()
-	hc := Checker{Name: "Test", URL: endpt, Attempts: 2}
+	hc := Checker{Name: "Test", URL: endpt, Attempts: 2, Tags: map[string]string{"env": "test"}}
 
 	// Try an up server
 	result, err := hc.Check()
@@ -27,6 +41,9 @@ func TestChecker(t *testing.T) {
 	if got, want := result.Endpoint, endpt; got != want {
 		t.Errorf("Expected result.Endpoint='%s', got '%s'", want, got)
 	}
+	if got, want := result.Tags["env"], "test"; got != want {
+		t.Errorf("Expected result.Tags[env]='%s', got '%s'", want, got)
+	}
 	if got, want := result.Down, false; got != want {
 		t.Errorf("Expected result.Down=%v, got %v", want, got)
 	}
@@ -147,3 +164,239 @@ func TestChecker(t *testing.T) {
 		t.Errorf("Expected result.Down=%v, got %v", want, got)
 	}
 }
+
+func TestCheckerWithAuth(t *testing.T) {
+	var tokenRequests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokenRequests, 1)
+		id, secret, _ := r.BasicAuth()
+		if r.FormValue("grant_type") != "client_credentials" || id != "checkup" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "invalid_client", "error_description": "bad credentials"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "token-%s", "token_type": "Bearer", "expires_in": 3600}`, r.FormValue("scope"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Basic dXNlcjpwYXNz", "Bearer from-file", "Bearer from-env", "Bearer token-read write":
+			fmt.Fprint(w, "OK")
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "checkup-http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("CHECKUP_TEST_TOKEN", "from-env")
+	defer os.Unsetenv("CHECKUP_TEST_TOKEN")
+	os.Setenv("CHECKUP_TEST_SECRET", "s3cret")
+	defer os.Unsetenv("CHECKUP_TEST_SECRET")
+
+	oauth2 := &OAuth2{TokenURL: srv.URL + "/token", ClientID: "checkup", ClientSecretEnv: "CHECKUP_TEST_SECRET", Scopes: []string{"read", "write"}}
+	for i, test := range []struct {
+		checker Checker
+		down    bool
+		err     string
+	}{
+		{Checker{}, true, "response status 401 Unauthorized"},
+		{Checker{BasicAuth: &BasicAuth{Username: "user", Password: "pass"}}, false, ""},
+		{Checker{BasicAuth: &BasicAuth{Username: "user", Password: "wrong"}}, true, "response status 401 Unauthorized"},
+		{Checker{BearerTokenFile: tokenFile}, false, ""},
+		{Checker{BearerTokenEnv: "CHECKUP_TEST_TOKEN"}, false, ""},
+		{Checker{OAuth2: oauth2}, false, ""},
+		{Checker{OAuth2: oauth2}, false, ""},
+		{Checker{OAuth2: &OAuth2{TokenURL: srv.URL + "/token", ClientID: "checkup", ClientSecret: "wrong"}}, true, "oauth2: token request failed with status 401 Unauthorized: invalid_client bad credentials"},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = srv.URL
+		tc.Attempts = 3
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if result.Down != test.down {
+			t.Errorf("Test %d: Expected result.Down=%v, got %v: %v", i, test.down, result.Down, result.Times)
+		}
+		if got := result.Times[0].Error; got != test.err {
+			t.Errorf("Test %d: Expected error '%s', got '%s'", i, test.err, got)
+		}
+	}
+
+	// tokens are reused across attempts and checks, but a
+	// failed request isn't cached
+	if got, want := atomic.LoadInt32(&tokenRequests), int32(4); got != want {
+		t.Errorf("Expected %d token requests, got %d", want, got)
+	}
+
+	for _, tc := range []Checker{
+		{URL: srv.URL, BasicAuth: &BasicAuth{Username: "user"}, BearerTokenEnv: "CHECKUP_TEST_TOKEN"},
+		{URL: srv.URL, BearerTokenFile: filepath.Join(dir, "missing")},
+		{URL: srv.URL, BearerTokenEnv: "CHECKUP_TEST_MISSING"},
+		{URL: srv.URL, OAuth2: &OAuth2{ClientID: "checkup"}},
+		{URL: srv.URL, OAuth2: &OAuth2{TokenURL: srv.URL + "/token", ClientID: "checkup", ClientSecretEnv: "CHECKUP_TEST_MISSING"}},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
+
+func TestCheckerWithRevokedToken(t *testing.T) {
+	var tokenRequests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&tokenRequests, 1)
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 3600}`, n)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// the first token has been revoked
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	hc := Checker{Name: "Test", URL: srv.URL, Attempts: 2, OAuth2: &OAuth2{TokenURL: srv.URL + "/token", ClientID: "checkup"}}
+	result, err := hc.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := result.Times[0].Error, "response status 401 Unauthorized"; got != want {
+		t.Errorf("Expected first attempt error '%s', got '%s'", want, got)
+	}
+	if got := result.Times[1].Error; got != "" {
+		t.Errorf("Expected second attempt to succeed with a new token, got '%s'", got)
+	}
+	if got, want := atomic.LoadInt32(&tokenRequests), int32(2); got != want {
+		t.Errorf("Expected %d token requests, got %d", want, got)
+	}
+}
+
+func TestCheckerWithTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup-http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, caKey := makeCert(t, dir, "ca", nil, nil)
+	makeCert(t, dir, "server", ca, caKey)
+	makeCert(t, dir, "client", ca, caKey)
+
+	serverPair, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "OK")
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverPair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	for i, test := range []struct {
+		checker Checker
+		healthy bool
+		err     string
+	}{
+		{Checker{TLSCAFile: caFile, TLSCertFile: certFile, TLSKeyFile: keyFile}, true, ""},
+		{Checker{TLSCAFile: caFile, TLSCertFile: certFile, TLSKeyFile: keyFile, ServerName: "localhost"}, true, ""},
+		{Checker{TLSCAFile: caFile, TLSCertFile: certFile, TLSKeyFile: keyFile, ServerName: "example.com"}, false, "certificate is valid for localhost"},
+		{Checker{TLSSkipVerify: true, TLSCertFile: certFile, TLSKeyFile: keyFile}, true, ""},
+		{Checker{TLSCertFile: certFile, TLSKeyFile: keyFile}, false, "certificate signed by unknown authority"},
+		{Checker{TLSCAFile: caFile}, false, "remote error: tls"},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = srv.URL
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if result.Healthy != test.healthy {
+			t.Errorf("Test %d: Expected result.Healthy=%v, got %v: %v", i, test.healthy, result.Healthy, result.Times)
+		}
+		if got := result.Times[0].Error; !strings.Contains(got, test.err) {
+			t.Errorf("Test %d: Expected error containing '%s', got '%s'", i, test.err, got)
+		}
+	}
+
+	for _, tc := range []Checker{
+		{URL: srv.URL, TLSCAFile: filepath.Join(dir, "missing.pem")},
+		{URL: srv.URL, TLSCAFile: keyFile},
+		{URL: srv.URL, TLSCertFile: certFile},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
+
+// makeCert creates a certificate for localhost signed by
+// parent, or a CA certificate if parent is nil, and writes it
+// and its key to name.pem and name.key in dir.
+func makeCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
@@ -0,0 +1,128 @@
+package imap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// client is a minimal IMAP4rev1 client.
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// newClient returns a client over conn, once the server has
+// greeted it.
+func newClient(conn net.Conn) (*client, error) {
+	c := &client{conn: conn, r: bufio.NewReader(conn)}
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "* OK") && !strings.HasPrefix(line, "* PREAUTH") {
+		return nil, fmt.Errorf("unexpected greeting: %s", line)
+	}
+	return c, nil
+}
+
+// cmd sends command and returns the untagged responses to it.
+// An error is returned unless the command completes with OK.
+func (c *client) cmd(command string) ([]string, error) {
+	c.tag++
+	tag := "a" + strconv.Itoa(c.tag)
+	if _, err := io.WriteString(c.conn, tag+" "+command+"\r\n"); err != nil {
+		return nil, err
+	}
+
+	var untagged []string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "* ") {
+			untagged = append(untagged, line[2:])
+			continue
+		}
+		if !strings.HasPrefix(line, tag+" ") {
+			continue
+		}
+		status := strings.TrimPrefix(line, tag+" ")
+		if !strings.HasPrefix(status, "OK") {
+			return untagged, errors.New(status)
+		}
+		return untagged, nil
+	}
+}
+
+// startTLS upgrades the connection to TLS.
+func (c *client) startTLS(config *tls.Config) error {
+	if _, err := c.cmd("STARTTLS"); err != nil {
+		return err
+	}
+	tlsConn := tls.Client(c.conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+	c.conn, c.r = tlsConn, bufio.NewReader(tlsConn)
+	return nil
+}
+
+// maxLineSize is the size of the longest response line,
+// including its literals, that the server may send.
+const maxLineSize = 1 << 20
+
+// readLine reads a response line. Literals ({n} followed by n
+// bytes) are inlined as quoted strings.
+func (c *client) readLine() (string, error) {
+	var line strings.Builder
+	for {
+		s, err := c.r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		s = strings.TrimRight(s, "\r\n")
+		n, ok := literal(s)
+		if !ok {
+			line.WriteString(s)
+			return line.String(), nil
+		}
+		line.WriteString(s[:strings.LastIndex(s, "{")])
+		if n > maxLineSize-line.Len() {
+			return "", fmt.Errorf("response longer than %d bytes", maxLineSize)
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return "", err
+		}
+		line.WriteString(quote(string(buf)))
+	}
+}
+
+// literal returns the length of the literal that s ends with,
+// if any.
+func literal(s string) (int, bool) {
+	if !strings.HasSuffix(s, "}") {
+		return 0, false
+	}
+	i := strings.LastIndex(s, "{")
+	if i < 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[i+1 : len(s)-1])
+	return n, err == nil && n >= 0
+}
+
+// quote returns s as an IMAP quoted string.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
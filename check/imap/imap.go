@@ -0,0 +1,165 @@
+package imap
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/mailcheck"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "imap"
+
+// internalDateLayout is the layout of INTERNALDATE values.
+const internalDateLayout = "_2-Jan-2006 15:04:05 -0700"
+
+// Checker implements a Checker for IMAP servers.
+//
+// Each attempt logs in and examines Mailbox, which must exist.
+// If Subject is set, a message whose subject contains it must
+// be in the mailbox, and must have arrived within MaxAge if
+// set. Together with an smtp checker sending test messages,
+// this monitors mail delivery end to end.
+type Checker struct {
+	mailcheck.Checker
+
+	// Mailbox is the mailbox to examine. Default is
+	// INBOX.
+	Mailbox string `json:"mailbox,omitempty"`
+
+	// Subject is a string that the subject of a message
+	// in Mailbox must contain in order to be considered
+	// up.
+	Subject string `json:"subject,omitempty"`
+
+	// MaxAge is how recently the newest message matching
+	// Subject must have arrived in order to be considered
+	// up. If zero, any message matches.
+	MaxAge time.Duration `json:"max_age,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Username == "" {
+		return types.Result{}, errors.New("imap: missing username")
+	}
+	if c.MaxAge > 0 && c.Subject == "" {
+		return types.Result{}, errors.New("imap: max_age requires a subject")
+	}
+	if err := c.CheckCleartextAuth(); err != nil {
+		return types.Result{}, fmt.Errorf("imap: %w", err)
+	}
+	if c.Mailbox == "" {
+		c.Mailbox = "INBOX"
+	}
+	return c.Checker.Check(c.session)
+}
+
+// session logs in and looks for the message over conn.
+func (c Checker) session(conn net.Conn, tlsConfig *tls.Config) error {
+	client, err := newClient(conn)
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		if err := client.startTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if _, err := client.cmd("LOGIN " + quote(c.Username) + " " + quote(c.Password)); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	if _, err := client.cmd("EXAMINE " + quote(c.Mailbox)); err != nil {
+		return fmt.Errorf("mailbox %s: %w", c.Mailbox, err)
+	}
+	if c.Subject != "" {
+		if err := c.findMessage(client); err != nil {
+			return err
+		}
+	}
+	_, err = client.cmd("LOGOUT")
+	return err
+}
+
+// findMessage looks for the newest message matching c.Subject
+// and checks that it arrived within c.MaxAge.
+func (c Checker) findMessage(client *client) error {
+	search := "SEARCH SUBJECT " + quote(c.Subject)
+	if c.MaxAge > 0 {
+		// SINCE is by date only, so the age is checked below
+		search += " SINCE " + time.Now().Add(-c.MaxAge).Format("2-Jan-2006")
+	}
+	untagged, err := client.cmd(search)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	newest := 0
+	for _, line := range untagged {
+		if !strings.HasPrefix(line, "SEARCH") {
+			continue
+		}
+		for _, field := range strings.Fields(line)[1:] {
+			if n, err := strconv.Atoi(field); err == nil && n > newest {
+				newest = n
+			}
+		}
+	}
+	if newest == 0 {
+		return c.notFound()
+	}
+	if c.MaxAge == 0 {
+		return nil
+	}
+
+	untagged, err = client.cmd(fmt.Sprintf("FETCH %d (INTERNALDATE)", newest))
+	if err != nil {
+		return fmt.Errorf("fetch: %w", err)
+	}
+	for _, line := range untagged {
+		i := strings.Index(line, `INTERNALDATE "`)
+		if i < 0 {
+			continue
+		}
+		value := line[i+len(`INTERNALDATE "`):]
+		if j := strings.Index(value, `"`); j >= 0 {
+			value = value[:j]
+		}
+		date, err := time.Parse(internalDateLayout, value)
+		if err != nil {
+			return fmt.Errorf("fetch: %w", err)
+		}
+		if age := time.Since(date); age > c.MaxAge {
+			return c.notFound()
+		}
+		return nil
+	}
+	return errors.New("fetch: no INTERNALDATE in response")
+}
+
+// notFound returns the error for a missing message.
+func (c Checker) notFound() error {
+	if c.MaxAge > 0 {
+		return fmt.Errorf("no message with subject '%s' in the last %s", c.Subject, c.MaxAge)
+	}
+	return fmt.Errorf("no message with subject '%s'", c.Subject)
+}
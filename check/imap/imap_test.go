@@ -0,0 +1,179 @@
+package imap
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/mailcheck"
+)
+
+// message is a message in the fake mailbox.
+type message struct {
+	subject string
+	date    time.Time
+}
+
+func TestChecker(t *testing.T) {
+	ln := serve(t, []message{
+		{"Checkup test message", time.Now().Add(-2 * time.Hour)},
+		{"Weekly report", time.Now().Add(-time.Hour)},
+		{"Checkup test message", time.Now().Add(-3 * time.Minute)},
+	})
+	defer ln.Close()
+
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+	}{
+		{Checker{}, "healthy", ""},
+		{Checker{Mailbox: "Archive"}, "down", "mailbox Archive: NO"},
+		{Checker{Subject: "Checkup test"}, "healthy", ""},
+		{Checker{Subject: "Checkup test", MaxAge: 5 * time.Minute}, "healthy", ""},
+		{Checker{Subject: "Weekly report", MaxAge: 5 * time.Minute}, "down", "no message with subject 'Weekly report' in the last 5m0s"},
+		{Checker{Subject: "Invoice"}, "down", "no message with subject 'Invoice'"},
+		{Checker{Checker: mailcheck.Checker{Password: "wrong"}}, "down", "login: NO"},
+		{Checker{Checker: mailcheck.Checker{StartTLS: true}}, "down", "starttls: BAD"},
+		{Checker{Checker: mailcheck.Checker{ThresholdRTT: time.Nanosecond}}, "degraded", "median round trip time exceeded threshold"},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = ln.Addr().String()
+		tc.Username = "checkup"
+		if tc.Password == "" {
+			tc.Password = "secret"
+		}
+		tc.Attempts = 2
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+	}
+
+	for _, tc := range []Checker{
+		{Checker: mailcheck.Checker{URL: ln.Addr().String()}},
+		{Checker: mailcheck.Checker{URL: ln.Addr().String(), Username: "checkup"}, MaxAge: time.Minute},
+		{Checker: mailcheck.Checker{URL: "mail.example.com:143", Username: "checkup"}},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
+
+// serve starts a fake IMAP server with an INBOX holding
+// messages, which accepts the password "secret".
+func serve(t *testing.T, messages []message) net.Listener {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn, messages)
+		}
+	}()
+	return ln
+}
+
+// handle runs an IMAP session over conn.
+func handle(conn net.Conn, messages []message) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.SplitN(strings.TrimRight(line, "\r\n"), " ", 3)
+		tag, verb, args := fields[0], fields[1], ""
+		if len(fields) > 2 {
+			args = fields[2]
+		}
+		switch verb {
+		case "LOGIN":
+			if args == `"checkup" "secret"` {
+				fmt.Fprintf(conn, "%s OK logged in\r\n", tag)
+			} else {
+				fmt.Fprintf(conn, "%s NO [AUTHENTICATIONFAILED] invalid credentials\r\n", tag)
+			}
+		case "EXAMINE":
+			if args == `"INBOX"` {
+				fmt.Fprintf(conn, "* %d EXISTS\r\n%s OK [READ-ONLY] done\r\n", len(messages), tag)
+			} else {
+				fmt.Fprintf(conn, "%s NO [NONEXISTENT] no such mailbox\r\n", tag)
+			}
+		case "SEARCH":
+			subject := strings.SplitN(args, `"`, 3)[1]
+			var since time.Time
+			if i := strings.Index(args, "SINCE "); i >= 0 {
+				since, _ = time.Parse("2-Jan-2006", args[i+len("SINCE "):])
+			}
+			result := "* SEARCH"
+			for i, msg := range messages {
+				if strings.Contains(msg.subject, subject) && !msg.date.Before(since) {
+					result += fmt.Sprintf(" %d", i+1)
+				}
+			}
+			fmt.Fprintf(conn, "%s\r\n%s OK done\r\n", result, tag)
+		case "FETCH":
+			var n int
+			fmt.Sscanf(args, "%d", &n)
+			date := messages[n-1].date.Format(internalDateLayout)
+			fmt.Fprintf(conn, "* %d FETCH (INTERNALDATE \"%s\")\r\n%s OK done\r\n", n, date, tag)
+		case "LOGOUT":
+			fmt.Fprintf(conn, "* BYE\r\n%s OK done\r\n", tag)
+			return
+		default:
+			fmt.Fprintf(conn, "%s BAD unknown command\r\n", tag)
+		}
+	}
+}
+
+func TestReadLineLiteral(t *testing.T) {
+	server, conn := net.Pipe()
+	defer server.Close()
+	go fmt.Fprint(server, "* OK ready\r\n* 1 FETCH (BODY[HEADER.FIELDS (SUBJECT)] {17}\r\nSubject: \"hi\"\r\n\r\n)\r\n")
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := c.readLine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `1 FETCH (BODY[HEADER.FIELDS (SUBJECT)] "Subject: \"hi\"` + "\r\n\r\n" + `")`; line != "* "+want {
+		t.Errorf("Expected line %q, got %q", "* "+want, line)
+	}
+}
+
+func TestReadLineLiteralTooLong(t *testing.T) {
+	server, conn := net.Pipe()
+	defer server.Close()
+	go fmt.Fprint(server, "* OK ready\r\n* 1 FETCH (BODY[] {9999999999}\r\n")
+
+	c, err := newClient(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.readLine(); err == nil || !strings.Contains(err.Error(), "response longer than") {
+		t.Errorf("Expected an error for an oversized literal, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package dbcheck
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// operators are the comparison operators of conditions,
+// longest first so that they are matched greedily.
+var operators = []string{"==", "!=", "<=", ">=", "=", "<", ">"}
+
+// Condition is a comparison of a scalar value against an
+// operand, such as "= 1" or "> 10".
+type Condition struct {
+	op      string
+	operand string
+}
+
+// ParseCondition parses s, an operator followed by an
+// operand, into a Condition. The operators are =, ==, !=, <,
+// <=, > and >=.
+func ParseCondition(s string) (Condition, error) {
+	s = strings.TrimSpace(s)
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			operand := strings.TrimSpace(s[len(op):])
+			if op == "==" {
+				op = "="
+			}
+			cond := Condition{op: op, operand: operand}
+			if op != "=" && op != "!=" {
+				if _, err := strconv.ParseFloat(operand, 64); err != nil {
+					return Condition{}, fmt.Errorf("operand of '%s' must be a number: %s", op, operand)
+				}
+			}
+			return cond, nil
+		}
+	}
+	return Condition{}, fmt.Errorf("condition must start with one of %s: %s", strings.Join(operators, " "), s)
+}
+
+// Match returns whether value satisfies c. Values are
+// compared as numbers if both are numbers, and as strings
+// otherwise.
+func (c Condition) Match(value string) bool {
+	v, err1 := strconv.ParseFloat(value, 64)
+	o, err2 := strconv.ParseFloat(c.operand, 64)
+	if err1 != nil || err2 != nil {
+		switch c.op {
+		case "=":
+			return value == c.operand
+		case "!=":
+			return value != c.operand
+		}
+		return false
+	}
+	switch c.op {
+	case "=":
+		return v == o
+	case "!=":
+		return v != o
+	case "<":
+		return v < o
+	case "<=":
+		return v <= o
+	case ">":
+		return v > o
+	case ">=":
+		return v >= o
+	}
+	return false
+}
+
+// String returns c in the form it was parsed from.
+func (c Condition) String() string {
+	return c.op + " " + c.operand
+}
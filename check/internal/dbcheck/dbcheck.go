@@ -0,0 +1,257 @@
+// Package dbcheck implements the logic shared by the checkers
+// of databases: connecting, running a query and asserting on
+// the scalar value it returns.
+package dbcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Conn is a connection to a database.
+type Conn interface {
+	// Query runs query and returns the first value of
+	// its result as a string.
+	Query(ctx context.Context, query string) (string, error)
+
+	// Close closes the connection.
+	Close() error
+}
+
+// ConnectFunc establishes a connection to a database, and
+// verifies that it is alive.
+type ConnectFunc func(ctx context.Context) (Conn, error)
+
+// Checker holds the configuration shared by database checkers.
+// Each attempt connects to the database and, if Query is set,
+// runs it and asserts on the value it returns. The median
+// connect and query times are reported as the "connect" and
+// "query" metrics of the result, along with the "value" of the
+// query if it is a number.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// DSN is the data source name (connection string) of
+	// the database.
+	DSN string `json:"dsn"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Query is run once connected, if set. The first
+	// value it returns is asserted with Expect and
+	// DegradedIf.
+	Query string `json:"query,omitempty"`
+
+	// Expect is a condition, such as "= 1" or "< 60",
+	// that the value of Query must satisfy in order to
+	// be considered up. The operators are =, !=, <, <=,
+	// > and >=.
+	Expect string `json:"expect,omitempty"`
+
+	// DegradedIf is a condition, such as "> 10", that
+	// marks the endpoint as degraded if the value of
+	// Query satisfies it.
+	DegradedIf string `json:"degraded_if,omitempty"`
+
+	// Timeout is the maximum time each attempt may
+	// take, including connecting and running Query.
+	// Default is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. If non-zero and a
+	// request takes longer than ThresholdRTT, the
+	// endpoint will be considered unhealthy. Note that
+	// this duration includes any in-between network
+	// latency.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many requests the client will
+	// make to the endpoint in a single check.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// attempt is the outcome of one successful attempt.
+type attempt struct {
+	connect, query time.Duration
+	value          string
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Check performs checks using c according to its configuration,
+// connecting with connect. endpoint describes the database in
+// the result; it should not include credentials. An error is
+// only returned if there is a configuration error.
+func (c Checker) Check(endpoint string, connect ConnectFunc) (types.Result, error) {
+	if c.DSN == "" {
+		return types.Result{}, errors.New("missing DSN")
+	}
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	var expect, degradedIf *Condition
+	for _, cond := range []struct {
+		s   string
+		dst **Condition
+	}{
+		{c.Expect, &expect},
+		{c.DegradedIf, &degradedIf},
+	} {
+		if cond.s == "" {
+			continue
+		}
+		if c.Query == "" {
+			return types.Result{}, errors.New("conditions require a query")
+		}
+		parsed, err := ParseCondition(cond.s)
+		if err != nil {
+			return types.Result{}, err
+		}
+		*cond.dst = &parsed
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = endpoint
+	result.Tags = c.Tags
+	var attempts []attempt
+	result.Times, attempts = c.doChecks(connect, expect)
+	result.Metrics = metrics(attempts)
+
+	return c.conclude(result, attempts, degradedIf), nil
+}
+
+// doChecks executes the checks and returns each attempt,
+// along with the outcome of the ones that got a reply. An
+// attempt whose value doesn't satisfy expect is an error.
+func (c Checker) doChecks(connect ConnectFunc, expect *Condition) (types.Attempts, []attempt) {
+	checks := make(types.Attempts, c.Attempts)
+	var attempts []attempt
+	for i := 0; i < c.Attempts; i++ {
+		start := time.Now()
+		a, err := c.attempt(connect)
+		checks[i].RTT = time.Since(start)
+		if err != nil {
+			checks[i].Error = err.Error()
+			continue
+		}
+		attempts = append(attempts, a)
+		if expect != nil && !expect.Match(a.value) {
+			checks[i].Error = fmt.Sprintf("query returned %s, expected %s", a.value, expect)
+		}
+	}
+	return checks, attempts
+}
+
+// attempt connects to the database and runs c.Query.
+func (c Checker) attempt(connect ConnectFunc) (attempt, error) {
+	var a attempt
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := connect(ctx)
+	a.connect = time.Since(start)
+	if err != nil {
+		return a, fmt.Errorf("connect: %w", err)
+	}
+	defer conn.Close()
+
+	if c.Query == "" {
+		return a, nil
+	}
+	start = time.Now()
+	a.value, err = conn.Query(ctx, c.Query)
+	a.query = time.Since(start)
+	if err != nil {
+		return a, fmt.Errorf("query: %w", err)
+	}
+	return a, nil
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It detects degraded (high-latency or DegradedIf) responses
+// and makes the conclusion about the result's status.
+func (c Checker) conclude(result types.Result, attempts []attempt, degradedIf *Condition) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Notice = result.Times[i].Error
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check value of query (degraded)
+	if degradedIf != nil {
+		for _, a := range attempts {
+			if degradedIf.Match(a.value) {
+				result.Notice = fmt.Sprintf("query returned %s, degraded if %s", a.value, degradedIf)
+				result.Degraded = true
+				return result
+			}
+		}
+	}
+
+	// Check round trip time (degraded)
+	if c.ThresholdRTT > 0 {
+		stats := result.ComputeStats()
+		if stats.Median > c.ThresholdRTT {
+			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// metrics returns the median connect and query times, in
+// seconds, and the value of the last query if it's a number.
+func metrics(attempts []attempt) []types.Metric {
+	if len(attempts) == 0 {
+		return nil
+	}
+	median := func(duration func(attempt) time.Duration) float64 {
+		times := make(types.Attempts, len(attempts))
+		for i, a := range attempts {
+			times[i].RTT = duration(a)
+		}
+		return types.Result{Times: times}.ComputeStats().Median.Seconds()
+	}
+
+	metrics := []types.Metric{
+		{Label: "connect", Value: median(func(a attempt) time.Duration { return a.connect }), Unit: "s"},
+	}
+	last := attempts[len(attempts)-1]
+	if last.query > 0 {
+		metrics = append(metrics, types.Metric{Label: "query", Value: median(func(a attempt) time.Duration { return a.query }), Unit: "s"})
+	}
+	if value, err := strconv.ParseFloat(last.value, 64); err == nil {
+		metrics = append(metrics, types.Metric{Label: "value", Value: value})
+	}
+	return metrics
+}
@@ -0,0 +1,110 @@
+package dbcheck
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeConn struct {
+	value string
+	err   error
+}
+
+func (c fakeConn) Query(ctx context.Context, query string) (string, error) {
+	return c.value, c.err
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func TestChecker(t *testing.T) {
+	connect := func(value string, err error) ConnectFunc {
+		return func(ctx context.Context) (Conn, error) {
+			return fakeConn{value, err}, nil
+		}
+	}
+	refused := func(ctx context.Context) (Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	for i, test := range []struct {
+		checker Checker
+		connect ConnectFunc
+		status  string
+		notice  string
+		metrics int
+	}{
+		{Checker{}, connect("", nil), "healthy", "", 1},
+		{Checker{Query: "SELECT 1", Expect: "= 1"}, connect("1", nil), "healthy", "", 3},
+		{Checker{Query: "SELECT 1", Expect: "== 1"}, connect("2", nil), "down", "query returned 2, expected = 1", 3},
+		{Checker{Query: "SELECT role", Expect: "= primary"}, connect("primary", nil), "healthy", "", 2},
+		{Checker{Query: "SELECT lag", Expect: "< 60", DegradedIf: "> 10"}, connect("4.5", nil), "healthy", "", 3},
+		{Checker{Query: "SELECT lag", Expect: "< 60", DegradedIf: "> 10"}, connect("12.5", nil), "degraded", "query returned 12.5, degraded if > 10", 3},
+		{Checker{Query: "SELECT lag", Expect: "< 60", DegradedIf: "> 10"}, connect("90", nil), "down", "query returned 90, expected < 60", 3},
+		{Checker{Query: "SELECT 1"}, connect("", errors.New("syntax error")), "down", "query: syntax error", 0},
+		{Checker{}, refused, "down", "connect: connection refused", 0},
+		{Checker{ThresholdRTT: time.Nanosecond}, connect("", nil), "degraded", "median round trip time exceeded threshold", 1},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.DSN = "fake"
+		tc.Attempts = 2
+		result, err := tc.Check("fake", test.connect)
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+		if got, want := len(result.Metrics), test.metrics; got != want {
+			t.Errorf("Test %d: Expected %d metrics, got %v", i, want, result.Metrics)
+		}
+	}
+
+	for _, tc := range []Checker{
+		{Query: "SELECT 1"},
+		{DSN: "fake", Expect: "= 1"},
+		{DSN: "fake", Query: "SELECT 1", Expect: "1"},
+		{DSN: "fake", Query: "SELECT 1", DegradedIf: "> ten"},
+	} {
+		if _, err := tc.Check("fake", connect("1", nil)); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
+
+func TestCondition(t *testing.T) {
+	for i, test := range []struct {
+		condition string
+		value     string
+		match     bool
+	}{
+		{"= 1", "1", true},
+		{"=1", "1.0", true},
+		{"!= 1", "1", false},
+		{"< 10", "9.5", true},
+		{"<= 10", "10", true},
+		{"> 10", "10", false},
+		{">= 10", "10", true},
+		{"> 10", "abc", false},
+		{"= primary", "primary", true},
+		{"!= primary", "replica", true},
+	} {
+		c, err := ParseCondition(test.condition)
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := c.Match(test.value), test.match; got != want {
+			t.Errorf("Test %d: Expected '%s' matching %s to be %v, got %v", i, test.condition, test.value, want, got)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package dbcheck
+
+import (
+	"context"
+	"database/sql"
+)
+
+// SQL returns a ConnectFunc that opens dsn with the database/sql
+// driver named driverName, and pings it.
+func SQL(driverName, dsn string) ConnectFunc {
+	return func(ctx context.Context) (Conn, error) {
+		db, err := sql.Open(driverName, dsn)
+		if err != nil {
+			return nil, err
+		}
+		db.SetMaxOpenConns(1)
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			return nil, err
+		}
+		return sqlConn{db}, nil
+	}
+}
+
+// sqlConn is a Conn over a database/sql database.
+type sqlConn struct {
+	db *sql.DB
+}
+
+// Query returns the first column of the first row of the result
+// of query. A NULL value is returned as "NULL".
+func (c sqlConn) Query(ctx context.Context, query string) (string, error) {
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", sql.ErrNoRows
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return "", err
+	}
+	if len(values) == 0 || !values[0].Valid {
+		return "NULL", nil
+	}
+	return values[0].String, nil
+}
+
+// Close closes the database.
+func (c sqlConn) Close() error {
+	return c.db.Close()
+}
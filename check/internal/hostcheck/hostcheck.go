@@ -0,0 +1,93 @@
+// Package hostcheck implements the logic shared by the checkers
+// of local host resources: comparing values against degraded
+// and down thresholds, and concluding the result from them.
+package hostcheck
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Evaluation collects the values of a check, as metrics, and
+// the problems found by comparing them against thresholds.
+type Evaluation struct {
+	Metrics  []types.Metric
+	down     []string
+	degraded []string
+}
+
+// Metric records a value that isn't compared to thresholds.
+func (e *Evaluation) Metric(label string, value float64, unit string) {
+	e.Metrics = append(e.Metrics, types.Metric{Label: label, Value: value, Unit: unit})
+}
+
+// Min records value, which must not drop below degraded and
+// down. Thresholds that are zero are not checked.
+func (e *Evaluation) Min(label string, value float64, unit string, degraded, down float64) {
+	e.Metrics = append(e.Metrics, metric(label, value, unit, degraded, down, ":"))
+	switch {
+	case down > 0 && value < down:
+		e.down = append(e.down, fmt.Sprintf("%s %s%s is below %s%s", label, format(value), unit, format(down), unit))
+	case degraded > 0 && value < degraded:
+		e.degraded = append(e.degraded, fmt.Sprintf("%s %s%s is below %s%s", label, format(value), unit, format(degraded), unit))
+	}
+}
+
+// Max records value, which must not exceed degraded and down.
+// Thresholds that are zero are not checked.
+func (e *Evaluation) Max(label string, value float64, unit string, degraded, down float64) {
+	e.Metrics = append(e.Metrics, metric(label, value, unit, degraded, down, ""))
+	switch {
+	case down > 0 && value > down:
+		e.down = append(e.down, fmt.Sprintf("%s %s%s is above %s%s", label, format(value), unit, format(down), unit))
+	case degraded > 0 && value > degraded:
+		e.degraded = append(e.degraded, fmt.Sprintf("%s %s%s is above %s%s", label, format(value), unit, format(degraded), unit))
+	}
+}
+
+// Down records a problem that makes the check down.
+func (e *Evaluation) Down(format string, args ...interface{}) {
+	e.down = append(e.down, fmt.Sprintf(format, args...))
+}
+
+// Conclude stores the metrics in result, and makes the
+// conclusion about its status: down if any value crossed its
+// down threshold, degraded if any crossed its degraded
+// threshold, and healthy otherwise. The notice lists the
+// problems of the worst status.
+func (e *Evaluation) Conclude(result types.Result) types.Result {
+	result.Metrics = e.Metrics
+	switch {
+	case len(e.down) > 0:
+		result.Notice = strings.Join(e.down, "; ")
+		result.Down = true
+	case len(e.degraded) > 0:
+		result.Notice = strings.Join(e.degraded, "; ")
+		result.Degraded = true
+	default:
+		result.Healthy = true
+	}
+	return result
+}
+
+// metric returns a metric with its thresholds as Nagios
+// ranges, which are suffixed with ":" for minimums.
+func metric(label string, value float64, unit string, degraded, down float64, suffix string) types.Metric {
+	m := types.Metric{Label: label, Value: value, Unit: unit}
+	if degraded > 0 {
+		m.Warn = format(degraded) + suffix
+	}
+	if down > 0 {
+		m.Crit = format(down) + suffix
+	}
+	return m
+}
+
+// format formats v with at most 2 decimals.
+func format(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
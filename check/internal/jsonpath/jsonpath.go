@@ -0,0 +1,135 @@
+// Package jsonpath implements the subset of JSONPath needed to
+// select a single value from a JSON document, such as
+// $.status, $.items[0].name, $['key with spaces'] or
+// $.items[-1] (the last item).
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// step is one step of a Path: a key of an object, or an index
+// of an array if key is empty.
+type step struct {
+	key   string
+	index int
+}
+
+// Path is a parsed JSONPath expression.
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// Parse parses expr into a Path. The leading $ is optional.
+func Parse(expr string) (Path, error) {
+	p := Path{expr: expr}
+	s := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	for s != "" {
+		switch {
+		case s[0] == '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return Path{}, fmt.Errorf("jsonpath: empty key in %s", expr)
+			}
+			p.steps = append(p.steps, step{key: s[:end]})
+			s = s[end:]
+		case strings.HasPrefix(s, "['") || strings.HasPrefix(s, `["`):
+			quote := s[1]
+			end := strings.IndexByte(s[2:], quote)
+			if end < 0 || !strings.HasPrefix(s[2+end+1:], "]") {
+				return Path{}, fmt.Errorf("jsonpath: unterminated key in %s", expr)
+			}
+			p.steps = append(p.steps, step{key: s[2 : 2+end]})
+			s = s[2+end+2:]
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return Path{}, fmt.Errorf("jsonpath: unterminated index in %s", expr)
+			}
+			index, err := strconv.Atoi(s[1:end])
+			if err != nil {
+				return Path{}, fmt.Errorf("jsonpath: invalid index in %s: %s", expr, s[1:end])
+			}
+			p.steps = append(p.steps, step{index: index})
+			s = s[end+1:]
+		case len(p.steps) == 0:
+			// a leading key without a dot, as in "status"
+			s = "." + s
+		default:
+			return Path{}, fmt.Errorf("jsonpath: unexpected %q in %s", s[0], expr)
+		}
+	}
+	return p, nil
+}
+
+// String returns the expression p was parsed from.
+func (p Path) String() string {
+	return p.expr
+}
+
+// Get returns the value that p selects in v, a document decoded
+// by encoding/json into an interface{}, and whether it exists.
+func (p Path) Get(v interface{}) (interface{}, bool) {
+	for _, st := range p.steps {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			if st.key == "" {
+				return nil, false
+			}
+			var ok bool
+			if v, ok = node[st.key]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			if st.key != "" {
+				return nil, false
+			}
+			index := st.index
+			if index < 0 {
+				index += len(node)
+			}
+			if index < 0 || index >= len(node) {
+				return nil, false
+			}
+			v = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// Lookup decodes doc and returns the value that p selects in
+// it, formatted by Format.
+func (p Path) Lookup(doc []byte) (string, error) {
+	var v interface{}
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return "", err
+	}
+	value, ok := p.Get(v)
+	if !ok {
+		return "", fmt.Errorf("%s not found", p)
+	}
+	return Format(value), nil
+}
+
+// Format formats v, a value decoded by encoding/json, as a
+// string: strings as they are, and anything else as JSON.
+func Format(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,46 @@
+package jsonpath
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	doc := []byte(`{"status": "ok", "lag": 1.5, "items": [{"name": "a"}, {"name": "b"}], "key with spaces": {"n": null}, "ok": true}`)
+	for i, test := range []struct {
+		expr  string
+		value string
+		err   bool
+	}{
+		{"$.status", "ok", false},
+		{"status", "ok", false},
+		{"$.lag", "1.5", false},
+		{"$.ok", "true", false},
+		{"$.items[0].name", "a", false},
+		{"$.items[-1].name", "b", false},
+		{"$['key with spaces'].n", "null", false},
+		{`$["items"][1]`, `{"name":"b"}`, false},
+		{"$", `{"items":[{"name":"a"},{"name":"b"}],"key with spaces":{"n":null},"lag":1.5,"ok":true,"status":"ok"}`, false},
+		{"$.missing", "", true},
+		{"$.items[2]", "", true},
+		{"$.items.name", "", true},
+		{"$.status[0]", "", true},
+	} {
+		p, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error parsing %s: %v", i, test.expr, err)
+			continue
+		}
+		value, err := p.Lookup(doc)
+		if test.err != (err != nil) {
+			t.Errorf("Test %d: Expected error=%v, got %v", i, test.err, err)
+			continue
+		}
+		if value != test.value {
+			t.Errorf("Test %d: Expected %s to be %s, got %s", i, test.expr, test.value, value)
+		}
+	}
+
+	for _, expr := range []string{"$.", "$.items[x]", "$.items[0", "$['name", "$..name"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Expected an error parsing %s, didn't get one", expr)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Package mailcheck implements the logic shared by the checkers
+// of mail servers: connecting, with TLS if configured, and
+// running a protocol session within a deadline.
+package mailcheck
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// SessionFunc runs a protocol session over conn, such as
+// greeting the server and logging in. If the checker is
+// configured for STARTTLS, tlsConfig is the configuration to
+// upgrade the connection with; otherwise it is nil.
+type SessionFunc func(conn net.Conn, tlsConfig *tls.Config) error
+
+// Checker holds the configuration shared by mail checkers.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the address of the server, as host:port.
+	URL string `json:"endpoint_url"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// TLSEnabled controls whether to connect with TLS
+	// from the start, as on ports 465, 993 and 995.
+	TLSEnabled bool `json:"tls,omitempty"`
+
+	// StartTLS controls whether to upgrade the
+	// connection to TLS with the protocol's STARTTLS
+	// command. The server must support it.
+	StartTLS bool `json:"starttls,omitempty"`
+
+	// TLSSkipVerify controls whether to skip server TLS
+	// certificate validation or not.
+	TLSSkipVerify bool `json:"tls_skip_verify,omitempty"`
+
+	// TLSCAFile is the Certificate Authority used
+	// to validate the server TLS certificate.
+	TLSCAFile string `json:"tls_ca_file,omitempty"`
+
+	// Username and Password are the credentials to log
+	// in with.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// AllowInsecureAuth allows checkers that send
+	// credentials in cleartext to log in to hosts other
+	// than localhost without TLS or STARTTLS.
+	AllowInsecureAuth bool `json:"allow_insecure_auth,omitempty"`
+
+	// Timeout is the maximum time each attempt may
+	// take, from connecting to logging out. Default is
+	// 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. If non-zero and a
+	// request takes longer than ThresholdRTT, the
+	// endpoint will be considered unhealthy. Note that
+	// this duration includes any in-between network
+	// latency.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many requests the client will
+	// make to the endpoint in a single check.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Check performs checks using c according to its configuration,
+// running session over each connection. An error is only
+// returned if there is a configuration error.
+func (c Checker) Check(session SessionFunc) (types.Result, error) {
+	if c.URL == "" {
+		return types.Result{}, errors.New("missing endpoint_url")
+	}
+	if c.TLSEnabled && c.StartTLS {
+		return types.Result{}, errors.New("tls and starttls are mutually exclusive")
+	}
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return types.Result{}, err
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+	result.Tags = c.Tags
+	result.Times = c.doChecks(session, tlsConfig)
+
+	return c.conclude(result), nil
+}
+
+// CheckCleartextAuth returns an error if logging in to c.URL
+// would send credentials in cleartext over the network: that
+// is, if neither TLS nor STARTTLS is enabled, the host is not
+// localhost and c.AllowInsecureAuth is not set. Like PlainAuth
+// in net/smtp, it refuses rather than leak the password.
+func (c Checker) CheckCleartextAuth() error {
+	if c.TLSEnabled || c.StartTLS || c.AllowInsecureAuth {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(c.URL)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" || net.ParseIP(host).IsLoopback() {
+		return nil
+	}
+	return errors.New("refusing to send credentials without tls or starttls; set allow_insecure_auth to override")
+}
+
+// tlsConfig returns the TLS config described by c.
+func (c Checker) tlsConfig() (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(c.URL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.TLSSkipVerify,
+		ServerName:         host,
+	}
+	if c.TLSCAFile != "" {
+		rootPEM, err := ioutil.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading root certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(rootPEM) {
+			return nil, errors.New("error parsing root certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// doChecks executes and returns each attempt.
+func (c Checker) doChecks(session SessionFunc, tlsConfig *tls.Config) types.Attempts {
+	checks := make(types.Attempts, c.Attempts)
+	for i := 0; i < c.Attempts; i++ {
+		start := time.Now()
+		err := c.attempt(session, tlsConfig)
+		checks[i].RTT = time.Since(start)
+		if err != nil {
+			checks[i].Error = err.Error()
+		}
+	}
+	return checks
+}
+
+// attempt connects to the server and runs session.
+func (c Checker) attempt(session SessionFunc, tlsConfig *tls.Config) error {
+	dialer := &net.Dialer{Timeout: c.Timeout}
+	var conn net.Conn
+	var err error
+	if c.TLSEnabled {
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.URL, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", c.URL)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return err
+	}
+	if !c.StartTLS {
+		tlsConfig = nil
+	}
+	return session(conn, tlsConfig)
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It detects degraded (high-latency) responses and makes
+// the conclusion about the result's status.
+func (c Checker) conclude(result types.Result) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Notice = result.Times[i].Error
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check round trip time (degraded)
+	if c.ThresholdRTT > 0 {
+		stats := result.ComputeStats()
+		if stats.Median > c.ThresholdRTT {
+			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	result.Healthy = true
+	return result
+}
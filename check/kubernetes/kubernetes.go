@@ -0,0 +1,434 @@
+package kubernetes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "kubernetes"
+
+// serviceAccountDir holds the credentials of the service
+// account of pods, which are used when running in a cluster.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Checker implements a Checker for Kubernetes Deployments,
+// which queries the API server for the status of a Deployment
+// and of its pods.
+//
+// A Deployment is down if fewer than MinReady of its replicas
+// are ready, and degraded if its containers restarted more
+// than MaxRestarts times within RestartWindow. The ready and
+// desired replicas and the recent restarts are reported as
+// the "ready_replicas", "replicas" and "restarts" metrics of
+// the result.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the URL of the API server. If empty, the
+	// checker assumes it runs in a pod and uses the
+	// in-cluster address, token and CA certificate.
+	URL string `json:"endpoint_url,omitempty"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Namespace is the namespace of the Deployment.
+	// Default is "default".
+	Namespace string `json:"namespace,omitempty"`
+
+	// Deployment is the name of the Deployment.
+	Deployment string `json:"deployment"`
+
+	// MinReady is the minimum number of ready replicas.
+	// Default is the number of desired replicas.
+	MinReady int `json:"min_ready,omitempty"`
+
+	// MaxRestarts is the number of container restarts
+	// allowed within RestartWindow before the Deployment
+	// is considered degraded. Negative disables the
+	// check.
+	MaxRestarts int `json:"max_restarts,omitempty"`
+
+	// RestartWindow is how far back restarts count as
+	// recent. Default is 1 hour.
+	RestartWindow time.Duration `json:"restart_window,omitempty"`
+
+	// TokenFile is a file holding the bearer token with
+	// which to authenticate to the API server.
+	TokenFile string `json:"token_file,omitempty"`
+
+	// TLSCAFile is the Certificate Authority used
+	// to validate the server TLS certificate.
+	TLSCAFile string `json:"tls_ca_file,omitempty"`
+
+	// TLSSkipVerify controls whether to skip server TLS
+	// certificate validation or not.
+	TLSSkipVerify bool `json:"tls_skip_verify,omitempty"`
+
+	// Timeout is the maximum time to wait for each
+	// request to the API server. Default is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. If non-zero and a
+	// request takes longer than ThresholdRTT, the
+	// endpoint will be considered unhealthy. Note that
+	// this duration includes any in-between network
+	// latency.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many requests the client will
+	// make to the endpoint in a single check.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// deployment is the part of a Deployment that is checked.
+type deployment struct {
+	Spec struct {
+		Replicas *int     `json:"replicas"`
+		Selector selector `json:"selector"`
+	} `json:"spec"`
+	Status struct {
+		Replicas      int `json:"replicas"`
+		ReadyReplicas int `json:"readyReplicas"`
+	} `json:"status"`
+}
+
+// selector is a label selector.
+type selector struct {
+	MatchLabels      map[string]string `json:"matchLabels"`
+	MatchExpressions []struct {
+		Key      string   `json:"key"`
+		Operator string   `json:"operator"`
+		Values   []string `json:"values"`
+	} `json:"matchExpressions"`
+}
+
+// podList is the part of a list of pods that is checked.
+type podList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Status struct {
+			ContainerStatuses []struct {
+				Name         string `json:"name"`
+				RestartCount int    `json:"restartCount"`
+				LastState    struct {
+					Terminated *struct {
+						Reason     string    `json:"reason"`
+						FinishedAt time.Time `json:"finishedAt"`
+					} `json:"terminated"`
+				} `json:"lastState"`
+			} `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// status is the outcome of one successful attempt.
+type status struct {
+	desired, ready int
+
+	// restarts is the number of recent restarts, and
+	// lastRestart describes the latest of them.
+	restarts    int
+	lastRestart string
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Deployment == "" {
+		return types.Result{}, errors.New("kubernetes: missing deployment")
+	}
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.Namespace == "" {
+		c.Namespace = "default"
+	}
+	if c.RestartWindow == 0 {
+		c.RestartWindow = time.Hour
+	}
+	if c.URL == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return types.Result{}, errors.New("kubernetes: missing endpoint_url, and not running in a cluster")
+		}
+		c.URL = "https://" + net.JoinHostPort(host, port)
+		if c.TokenFile == "" {
+			c.TokenFile = serviceAccountDir + "/token"
+		}
+		if c.TLSCAFile == "" {
+			c.TLSCAFile = serviceAccountDir + "/ca.crt"
+		}
+	}
+	client, err := c.client()
+	if err != nil {
+		return types.Result{}, err
+	}
+
+	base := strings.TrimSuffix(c.URL, "/")
+	deploymentURL := fmt.Sprintf("%s/apis/apps/v1/namespaces/%s/deployments/%s", base, url.PathEscape(c.Namespace), url.PathEscape(c.Deployment))
+	podsURL := fmt.Sprintf("%s/api/v1/namespaces/%s/pods", base, url.PathEscape(c.Namespace))
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = deploymentURL
+	result.Tags = c.Tags
+	var last *status
+	result.Times, last = c.doChecks(client, deploymentURL, podsURL)
+
+	return c.conclude(result, last), nil
+}
+
+// client returns the client with which to query the API
+// server.
+func (c Checker) client() (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.TLSSkipVerify}
+	if c.TLSCAFile != "" {
+		rootPEM, err := ioutil.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes: reading CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(rootPEM) {
+			return nil, fmt.Errorf("kubernetes: no certificates in %s", c.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}
+	return &http.Client{Transport: transport, Timeout: c.Timeout}, nil
+}
+
+// doChecks executes the checks and returns each attempt, along
+// with the last status of the Deployment, if any. An attempt
+// is an error if too few replicas are ready.
+func (c Checker) doChecks(client *http.Client, deploymentURL, podsURL string) (types.Attempts, *status) {
+	checks := make(types.Attempts, c.Attempts)
+	var last *status
+	for i := 0; i < c.Attempts; i++ {
+		start := time.Now()
+		s, err := c.attempt(client, deploymentURL, podsURL)
+		checks[i].RTT = time.Since(start)
+		if err != nil {
+			checks[i].Error = err.Error()
+			continue
+		}
+		last = s
+		if s.ready < c.minReady(s) {
+			checks[i].Error = fmt.Sprintf("deployment has %d ready replicas, less than %d", s.ready, c.minReady(s))
+		}
+	}
+	return checks, last
+}
+
+// minReady returns the minimum number of ready replicas for
+// a Deployment with status s.
+func (c Checker) minReady(s *status) int {
+	if c.MinReady > 0 {
+		return c.MinReady
+	}
+	return s.desired
+}
+
+// attempt fetches the Deployment and its pods, and counts
+// the recent restarts of their containers.
+func (c Checker) attempt(client *http.Client, deploymentURL, podsURL string) (*status, error) {
+	var d deployment
+	if err := c.get(client, deploymentURL, &d); err != nil {
+		return nil, err
+	}
+	s := &status{desired: d.Status.Replicas, ready: d.Status.ReadyReplicas}
+	if d.Spec.Replicas != nil {
+		s.desired = *d.Spec.Replicas
+	}
+
+	labels, err := d.Spec.Selector.String()
+	if err != nil {
+		return nil, err
+	}
+	var pods podList
+	if err := c.get(client, podsURL+"?labelSelector="+url.QueryEscape(labels), &pods); err != nil {
+		return nil, err
+	}
+	since := time.Now().Add(-c.RestartWindow)
+	var latest time.Time
+	for _, pod := range pods.Items {
+		for _, ctr := range pod.Status.ContainerStatuses {
+			terminated := ctr.LastState.Terminated
+			if ctr.RestartCount == 0 || terminated == nil || terminated.FinishedAt.Before(since) {
+				continue
+			}
+			// only the last termination is known, so count
+			// the container once
+			s.restarts++
+			if terminated.FinishedAt.After(latest) {
+				latest = terminated.FinishedAt
+				s.lastRestart = fmt.Sprintf("pod %s, container %s: %s", pod.Metadata.Name, ctr.Name, terminated.Reason)
+			}
+		}
+	}
+	return s, nil
+}
+
+// get fetches u from the API server and decodes it into v.
+func (c Checker) get(client *http.Client, u string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.TokenFile != "" {
+		token, err := ioutil.ReadFile(c.TokenFile)
+		if err != nil {
+			return fmt.Errorf("reading token: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// errors are returned as a Status object
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return errors.New(apiErr.Message)
+		}
+		return fmt.Errorf("response status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// String returns s in the syntax of the labelSelector query
+// parameter, such as "app=web,tier in (frontend)".
+func (s selector) String() (string, error) {
+	var reqs []string
+	for key, value := range s.MatchLabels {
+		reqs = append(reqs, key+"="+value)
+	}
+	for _, expr := range s.MatchExpressions {
+		switch expr.Operator {
+		case "In":
+			reqs = append(reqs, fmt.Sprintf("%s in (%s)", expr.Key, strings.Join(expr.Values, ",")))
+		case "NotIn":
+			reqs = append(reqs, fmt.Sprintf("%s notin (%s)", expr.Key, strings.Join(expr.Values, ",")))
+		case "Exists":
+			reqs = append(reqs, expr.Key)
+		case "DoesNotExist":
+			reqs = append(reqs, "!"+expr.Key)
+		default:
+			return "", fmt.Errorf("unsupported selector operator %s", expr.Operator)
+		}
+	}
+	if len(reqs) == 0 {
+		return "", errors.New("deployment has no selector")
+	}
+	sort.Strings(reqs)
+	return strings.Join(reqs, ","), nil
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It detects degraded (restarting or high-latency)
+// Deployments and makes the conclusion about the result's
+// status.
+func (c Checker) conclude(result types.Result, last *status) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+	if last != nil {
+		result.Metrics = c.metrics(last)
+	}
+
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Notice = result.Times[i].Error
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check restarts (degraded)
+	if c.MaxRestarts >= 0 && last.restarts > c.MaxRestarts {
+		result.Notice = fmt.Sprintf("%d containers restarted in the last %s, more than %d (%s)", last.restarts, c.RestartWindow, c.MaxRestarts, last.lastRestart)
+		result.Degraded = true
+		return result
+	}
+
+	// Check round trip time (degraded)
+	if c.ThresholdRTT > 0 {
+		stats := result.ComputeStats()
+		if stats.Median > c.ThresholdRTT {
+			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// metrics returns the ready and desired replicas and the
+// recent restarts of s.
+func (c Checker) metrics(s *status) []types.Metric {
+	ready := types.Metric{Label: "ready_replicas", Value: float64(s.ready), Crit: fmt.Sprintf("%d:", c.minReady(s))}
+	restarts := types.Metric{Label: "restarts", Value: float64(s.restarts), Unit: "c"}
+	if c.MaxRestarts >= 0 {
+		restarts.Warn = fmt.Sprint(c.MaxRestarts)
+	}
+	return []types.Metric{
+		ready,
+		{Label: "replicas", Value: float64(s.desired)},
+		restarts,
+	}
+}
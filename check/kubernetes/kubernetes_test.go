@@ -0,0 +1,134 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// deployments are the replies of the stub API server, by name.
+var deployments = map[string]string{
+	"web":     `{"spec": {"replicas": 3, "selector": {"matchLabels": {"app": "web"}}}, "status": {"replicas": 3, "readyReplicas": 3}}`,
+	"partial": `{"spec": {"replicas": 3, "selector": {"matchLabels": {"app": "partial"}}}, "status": {"replicas": 3, "readyReplicas": 1}}`,
+	"crashy":  `{"spec": {"replicas": 2, "selector": {"matchLabels": {"app": "crashy"}, "matchExpressions": [{"key": "tier", "operator": "In", "values": ["backend"]}]}}, "status": {"replicas": 2, "readyReplicas": 2}}`,
+}
+
+// pods are the replies of the stub API server, by label
+// selector.
+var pods = map[string]string{
+	"app=web":     fmt.Sprintf(`{"items": [{"metadata": {"name": "web-1"}, "status": {"containerStatuses": [{"name": "app", "restartCount": 4, "lastState": {"terminated": {"reason": "Error", "finishedAt": "%s"}}}]}}]}`, time.Now().Add(-2*time.Hour).Format(time.RFC3339)),
+	"app=partial": `{"items": []}`,
+	"app=crashy,tier in (backend)": fmt.Sprintf(`{"items": [{"metadata": {"name": "crashy-1"}, "status": {"containerStatuses": [{"name": "app", "restartCount": 7, "lastState": {"terminated": {"reason": "OOMKilled", "finishedAt": "%s"}}}, {"name": "sidecar", "restartCount": 0}]}}]}`,
+		time.Now().Add(-5*time.Minute).Format(time.RFC3339)),
+}
+
+func TestChecker(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"kind": "Status", "message": "Unauthorized"}`))
+			return
+		}
+		var reply string
+		var ok bool
+		if strings.HasPrefix(r.URL.Path, "/apis/apps/v1/namespaces/prod/deployments/") {
+			reply, ok = deployments[strings.TrimPrefix(r.URL.Path, "/apis/apps/v1/namespaces/prod/deployments/")]
+		} else if r.URL.Path == "/api/v1/namespaces/prod/pods" {
+			reply, ok = pods[r.URL.Query().Get("labelSelector")]
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"kind": "Status", "message": "not found: " + r.URL.String()})
+			return
+		}
+		w.Write([]byte(reply))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "checkup-kubernetes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	wrongTokenFile := filepath.Join(dir, "wrong-token")
+	if err := ioutil.WriteFile(wrongTokenFile, []byte("wrong"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(dir, "ca.crt")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := ioutil.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+	}{
+		{Checker{Deployment: "web"}, "healthy", ""},
+		{Checker{Deployment: "web", RestartWindow: 3 * time.Hour}, "degraded", "1 containers restarted in the last 3h0m0s, more than 0 (pod web-1, container app: Error)"},
+		{Checker{Deployment: "partial"}, "down", "deployment has 1 ready replicas, less than 3"},
+		{Checker{Deployment: "partial", MinReady: 1}, "healthy", ""},
+		{Checker{Deployment: "crashy"}, "degraded", "1 containers restarted in the last 1h0m0s, more than 0 (pod crashy-1, container app: OOMKilled)"},
+		{Checker{Deployment: "crashy", MaxRestarts: 1}, "healthy", ""},
+		{Checker{Deployment: "crashy", MaxRestarts: -1}, "healthy", ""},
+		{Checker{Deployment: "missing"}, "down", "not found"},
+		{Checker{Deployment: "web", TokenFile: filepath.Join(dir, "missing")}, "down", "reading token"},
+		{Checker{Deployment: "web", TokenFile: wrongTokenFile}, "down", "Unauthorized"},
+		{Checker{Deployment: "web", ThresholdRTT: time.Nanosecond}, "degraded", "median round trip time exceeded threshold"},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = srv.URL
+		tc.Namespace = "prod"
+		tc.Attempts = 2
+		if tc.TokenFile == "" {
+			tc.TokenFile = tokenFile
+		}
+		tc.TLSCAFile = caFile
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+	}
+
+	// the certificate of the server must be trusted
+	tc := Checker{Name: "Test", URL: srv.URL, Namespace: "prod", Deployment: "web", TokenFile: tokenFile}
+	result, err := tc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	} else if !result.Down || !strings.Contains(result.Notice, "certificate") {
+		t.Errorf("Expected result.Down=true with a certificate error, got %s: %s", result.Status(), result.Notice)
+	}
+
+	os.Unsetenv("KUBERNETES_SERVICE_HOST")
+	for _, tc := range []Checker{
+		{URL: srv.URL},
+		{Deployment: "web"},
+		{URL: srv.URL, Deployment: "web", TLSCAFile: filepath.Join(dir, "missing")},
+		{URL: srv.URL, Deployment: "web", TLSCAFile: tokenFile},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
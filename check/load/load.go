@@ -0,0 +1,139 @@
+package load
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/hostcheck"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "load"
+
+// loadavgPath is where load averages are read from; overridden
+// in tests.
+var loadavgPath = "/proc/loadavg"
+
+// averages maps the values of Checker.Average to the index of
+// the load average in /proc/loadavg.
+var averages = map[string]int{"1": 0, "5": 1, "15": 2}
+
+// Checker implements a Checker for the load average of the
+// local host, read from /proc/loadavg. It only works on Linux.
+//
+// The load averages over 1, 5 and 15 minutes are reported as
+// the "load1", "load5" and "load15" metrics of the result,
+// along with the number of CPUs as "cpus".
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Average is the load average the thresholds apply
+	// to: "1", "5" or "15" minutes. Default is "5".
+	Average string `json:"average,omitempty"`
+
+	// PerCPU divides the load average by the number of
+	// CPUs before comparing it to the thresholds, so that
+	// the same thresholds fit hosts of any size.
+	PerCPU bool `json:"per_cpu,omitempty"`
+
+	// DegradedLoad and DownLoad are the load averages
+	// above which the host is degraded or down. Zero
+	// disables the threshold.
+	DegradedLoad float64 `json:"degraded_load,omitempty"`
+	DownLoad     float64 `json:"down_load,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Average == "" {
+		c.Average = "5"
+	}
+	index, ok := averages[c.Average]
+	if !ok {
+		return types.Result{}, fmt.Errorf("load: average must be 1, 5 or 15: %s", c.Average)
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = loadavgPath
+	result.Tags = c.Tags
+
+	start := time.Now()
+	var eval hostcheck.Evaluation
+	loads, err := readLoadavg()
+	if err != nil {
+		eval.Down("%v", err)
+	} else {
+		cpus := runtime.NumCPU()
+		for i, label := range []string{"load1", "load5", "load15"} {
+			if i != index {
+				eval.Metric(label, loads[i], "")
+				continue
+			}
+			load := loads[i]
+			if c.PerCPU {
+				eval.Metric(label, load, "")
+				label += "_per_cpu"
+				load /= float64(cpus)
+			}
+			eval.Max(label, load, "", c.DegradedLoad, c.DownLoad)
+		}
+		eval.Metric("cpus", float64(cpus), "")
+	}
+	result.Times = types.Attempts{{RTT: time.Since(start)}}
+
+	return eval.Conclude(result), nil
+}
+
+// readLoadavg returns the 1, 5 and 15 minute load averages.
+func readLoadavg() ([3]float64, error) {
+	var loads [3]float64
+	b, err := ioutil.ReadFile(loadavgPath)
+	if err != nil {
+		return loads, err
+	}
+	// e.g. "0.52 0.58 0.59 1/1234 5678"
+	fields := strings.Fields(string(b))
+	if len(fields) < 3 {
+		return loads, fmt.Errorf("parsing %s: too few fields", loadavgPath)
+	}
+	for i := range loads {
+		if loads[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return loads, fmt.Errorf("parsing %s: %v", loadavgPath, err)
+		}
+	}
+	return loads, nil
+}
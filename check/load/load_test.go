@@ -0,0 +1,54 @@
+package load
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestChecker(t *testing.T) {
+	defer func(path string) { loadavgPath = path }(loadavgPath)
+	loadavgPath = "testdata/loadavg"
+	perCPU := 3.5 / float64(runtime.NumCPU())
+
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+	}{
+		{Checker{}, "healthy", ""},
+		{Checker{DegradedLoad: 1.5, DownLoad: 4}, "degraded", "load5 2 is above 1.5"},
+		{Checker{DegradedLoad: 1.5, DownLoad: 1.8}, "down", "load5 2 is above 1.8"},
+		{Checker{Average: "15", DegradedLoad: 1.5}, "healthy", ""},
+		{Checker{Average: "1", DownLoad: 3}, "down", "load1 3.5 is above 3"},
+		{Checker{Average: "1", PerCPU: true, DownLoad: perCPU * 0.9}, "down", "load1_per_cpu"},
+		{Checker{Average: "1", PerCPU: true, DownLoad: perCPU * 1.1}, "healthy", ""},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+	}
+
+	if _, err := (Checker{Average: "10"}).Check(); err == nil {
+		t.Error("Expected an error for an invalid average, didn't get one")
+	}
+
+	loadavgPath = "testdata/missing"
+	result, err := Checker{}.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down {
+		t.Errorf("Expected result.Down=true, got %s", result.Status())
+	}
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/hostcheck"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "memory"
+
+// meminfoPath is where memory usage is read from; overridden
+// in tests.
+var meminfoPath = "/proc/meminfo"
+
+// Checker implements a Checker for the memory and swap usage of
+// the local host, read from /proc/meminfo. It only works on
+// Linux.
+//
+// The available memory and used swap are reported as the
+// "available" and "swap_used" metrics of the result, in
+// percent, along with "available_bytes".
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// DegradedAvailablePercent and DownAvailablePercent
+	// are the percentages of available memory below
+	// which the host is degraded or down. Zero disables
+	// the threshold.
+	DegradedAvailablePercent float64 `json:"degraded_available_percent,omitempty"`
+	DownAvailablePercent     float64 `json:"down_available_percent,omitempty"`
+
+	// DegradedSwapUsedPercent and DownSwapUsedPercent are
+	// the percentages of used swap above which the host
+	// is degraded or down. Zero disables the threshold.
+	DegradedSwapUsedPercent float64 `json:"degraded_swap_used_percent,omitempty"`
+	DownSwapUsedPercent     float64 `json:"down_swap_used_percent,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = meminfoPath
+	result.Tags = c.Tags
+
+	start := time.Now()
+	var eval hostcheck.Evaluation
+	info, err := readMeminfo()
+	if err != nil {
+		eval.Down("%v", err)
+	} else if info["MemTotal"] == 0 {
+		eval.Down("%s has no MemTotal", meminfoPath)
+	} else {
+		// MemAvailable is missing before Linux 3.14
+		available, ok := info["MemAvailable"]
+		if !ok {
+			available = info["MemFree"] + info["Buffers"] + info["Cached"]
+		}
+		eval.Min("available", percent(available, info["MemTotal"]), "%", c.DegradedAvailablePercent, c.DownAvailablePercent)
+		eval.Metric("available_bytes", float64(available), "B")
+		if info["SwapTotal"] > 0 {
+			used := info["SwapTotal"] - info["SwapFree"]
+			eval.Max("swap_used", percent(used, info["SwapTotal"]), "%", c.DegradedSwapUsedPercent, c.DownSwapUsedPercent)
+		}
+	}
+	result.Times = types.Attempts{{RTT: time.Since(start)}}
+
+	return eval.Conclude(result), nil
+}
+
+// readMeminfo returns the fields of /proc/meminfo, in bytes.
+func readMeminfo() (map[string]uint64, error) {
+	f, err := os.Open(meminfoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info := map[string]uint64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// e.g. "MemAvailable:    8041236 kB"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %v", meminfoPath, err)
+		}
+		if len(fields) > 2 && fields[2] == "kB" {
+			value *= 1024
+		}
+		info[strings.TrimSuffix(fields[0], ":")] = value
+	}
+	return info, scanner.Err()
+}
+
+// percent returns part as a percentage of total.
+func percent(part, total uint64) float64 {
+	return float64(part) / float64(total) * 100
+}
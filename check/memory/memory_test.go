@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChecker(t *testing.T) {
+	defer func(path string) { meminfoPath = path }(meminfoPath)
+
+	for i, test := range []struct {
+		meminfo string
+		checker Checker
+		status  string
+		notice  string
+		metrics int
+	}{
+		{"testdata/meminfo", Checker{}, "healthy", "", 3},
+		{"testdata/meminfo", Checker{DegradedAvailablePercent: 30, DownAvailablePercent: 10}, "degraded", "available 25% is below 30%", 3},
+		{"testdata/meminfo", Checker{DegradedAvailablePercent: 30, DownAvailablePercent: 26}, "down", "available 25% is below 26%", 3},
+		{"testdata/meminfo", Checker{DegradedSwapUsedPercent: 20}, "degraded", "swap_used 25% is above 20%", 3},
+		{"testdata/meminfo", Checker{DegradedAvailablePercent: 30, DownSwapUsedPercent: 20}, "down", "swap_used 25% is above 20%", 3},
+		{"testdata/meminfo-old", Checker{DownAvailablePercent: 20}, "healthy", "", 2},
+		{"testdata/meminfo-old", Checker{DownAvailablePercent: 25}, "down", "available 21.88% is below 25%", 2},
+		{"testdata/missing", Checker{}, "down", "open testdata/missing", 0},
+	} {
+		meminfoPath = test.meminfo
+		tc := test.checker
+		tc.Name = "Test"
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+		if got, want := len(result.Metrics), test.metrics; got != want {
+			t.Errorf("Test %d: Expected %d metrics, got %v", i, want, result.Metrics)
+		}
+	}
+}
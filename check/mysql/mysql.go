@@ -0,0 +1,58 @@
+package mysql
+
+import (
+	"encoding/json"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/sourcegraph/checkup/check/internal/dbcheck"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "mysql"
+
+// Checker implements a Checker for MySQL servers.
+//
+// Each attempt connects to the server with DSN, in the form
+// user:pass@tcp(host:3306)/db, and optionally runs Query. The
+// first value returned by Query can be asserted with Expect
+// and DegradedIf. Note that statements such as SHOW REPLICA
+// STATUS return many columns; use a SELECT of the value to
+// assert instead.
+type Checker struct {
+	dbcheck.Checker
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.DSN != "" {
+		if _, err := mysql.ParseDSN(c.DSN); err != nil {
+			return types.Result{}, err
+		}
+	}
+	return c.Checker.Check(endpoint(c.DSN), dbcheck.SQL("mysql", c.DSN))
+}
+
+// endpoint returns dsn without its password.
+func endpoint(dsn string) string {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil || cfg.Passwd == "" {
+		return dsn
+	}
+	cfg.Passwd = "xxxxx"
+	return cfg.FormatDSN()
+}
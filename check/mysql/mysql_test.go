@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestChecker(t *testing.T) {
+	// nothing listens on a closed listener's port
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	tc, err := New(json.RawMessage(`{"endpoint_name": "Test", "dsn": "checkup:secret@tcp(` + addr + `)/app", "query": "SELECT 1", "attempts": 2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := tc.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Down {
+		t.Errorf("Expected result.Down=true, got %s", result.Status())
+	}
+	if !strings.HasPrefix(result.Notice, "connect: ") {
+		t.Errorf("Expected notice to start with 'connect: ', got '%s'", result.Notice)
+	}
+	if got, want := result.Endpoint, "checkup:xxxxx@tcp("+addr+")/app"; got != want {
+		t.Errorf("Expected endpoint %s, got %s", want, got)
+	}
+
+	tc.DSN = "not a dsn"
+	if _, err := tc.Check(); err == nil {
+		t.Error("Expected an error for an invalid DSN, didn't get one")
+	}
+}
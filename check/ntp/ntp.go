@@ -0,0 +1,309 @@
+package ntp
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "ntp"
+
+// DefaultServer is the NTP server queried if none is set.
+const DefaultServer = "pool.ntp.org"
+
+// ntpEpochOffset is the number of seconds between the NTP
+// epoch (1900) and the Unix epoch (1970).
+const ntpEpochOffset = 2208988800
+
+// packetSize is the size of an NTP packet without extensions.
+const packetSize = 48
+
+// Checker implements a Checker that measures the offset of the
+// local clock against an NTP server, using SNTP over UDP.
+//
+// The offset and round trip delay of the attempt with the
+// lowest delay, which is the most accurate, are reported as
+// the "offset" and "delay" metrics of the result, along with
+// the "stratum" of the server.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the host and optional port of the NTP
+	// server. Default is pool.ntp.org on port 123.
+	URL string `json:"endpoint_url,omitempty"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// DegradedOffset and DownOffset are the absolute clock
+	// offsets above which the endpoint is degraded or
+	// down. Zero disables the threshold.
+	DegradedOffset time.Duration `json:"degraded_offset,omitempty"`
+	DownOffset     time.Duration `json:"down_offset,omitempty"`
+
+	// MaxStratum is the highest stratum allowed for the
+	// server; a server further from its reference clock
+	// is considered degraded. Zero disables the check.
+	MaxStratum int `json:"max_stratum,omitempty"`
+
+	// Timeout is the maximum time to wait for a reply.
+	// Default is 5 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. If non-zero and a
+	// request takes longer than ThresholdRTT, the
+	// endpoint will be considered unhealthy. Note that
+	// this duration includes any in-between network
+	// latency.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many requests the client will
+	// make to the endpoint in a single check.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// reply is the outcome of one successful query.
+type reply struct {
+	offset  time.Duration
+	delay   time.Duration
+	stratum int
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.URL == "" {
+		c.URL = DefaultServer
+	}
+	if c.DegradedOffset < 0 || c.DownOffset < 0 {
+		return types.Result{}, errors.New("ntp: offset thresholds must not be negative")
+	}
+	addr := c.URL
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), "123")
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+	result.Tags = c.Tags
+	var best *reply
+	result.Times, best = c.doChecks(addr)
+
+	return c.conclude(result, best), nil
+}
+
+// doChecks executes the checks and returns each attempt,
+// along with the reply with the lowest delay, if any.
+func (c Checker) doChecks(addr string) (types.Attempts, *reply) {
+	checks := make(types.Attempts, c.Attempts)
+	var best *reply
+	for i := 0; i < c.Attempts; i++ {
+		start := time.Now()
+		r, err := c.query(addr)
+		checks[i].RTT = time.Since(start)
+		if err != nil {
+			checks[i].Error = err.Error()
+			continue
+		}
+		if best == nil || r.delay < best.delay {
+			best = &r
+		}
+	}
+	return checks, best
+}
+
+// query sends a client request to addr and computes the clock
+// offset and round trip delay from its reply.
+func (c Checker) query(addr string) (reply, error) {
+	conn, err := net.DialTimeout("udp", addr, c.Timeout)
+	if err != nil {
+		return reply{}, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return reply{}, err
+	}
+
+	req := make([]byte, packetSize)
+	req[0] = 4<<3 | 3 // version 4, client mode
+	t1 := time.Now()
+	transmit := toNTP(t1)
+	binary.BigEndian.PutUint64(req[40:], transmit)
+	if _, err := conn.Write(req); err != nil {
+		return reply{}, err
+	}
+
+	resp := make([]byte, packetSize)
+	for {
+		n, err := conn.Read(resp)
+		if err != nil {
+			return reply{}, err
+		}
+		t4 := time.Now()
+		// ignore stray replies to earlier requests
+		if n < packetSize || binary.BigEndian.Uint64(resp[24:]) != transmit {
+			continue
+		}
+		return parse(resp, t1, t4)
+	}
+}
+
+// parse validates the server reply resp to a request sent at
+// t1 and received at t4, and computes the offset and delay.
+func parse(resp []byte, t1, t4 time.Time) (reply, error) {
+	leap, mode := resp[0]>>6, resp[0]&7
+	if mode != 4 {
+		return reply{}, fmt.Errorf("unexpected mode %d in reply", mode)
+	}
+	stratum := int(resp[1])
+	if stratum == 0 {
+		return reply{}, fmt.Errorf("server sent kiss code %s", strings.TrimRight(string(resp[12:16]), "\x00"))
+	}
+	if leap == 3 {
+		return reply{}, errors.New("server clock is not synchronized")
+	}
+	t2 := fromNTP(binary.BigEndian.Uint64(resp[32:]))
+	t3 := fromNTP(binary.BigEndian.Uint64(resp[40:]))
+	if t3.Before(t2) {
+		return reply{}, errors.New("invalid timestamps in reply")
+	}
+	return reply{
+		offset:  (t2.Sub(t1) + t3.Sub(t4)) / 2,
+		delay:   t4.Sub(t1) - t3.Sub(t2),
+		stratum: stratum,
+	}, nil
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It detects degraded (offset, stratum or high-latency)
+// responses and makes the conclusion about the result's
+// status.
+func (c Checker) conclude(result types.Result, best *reply) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+	if best != nil {
+		result.Metrics = c.metrics(*best)
+	}
+
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Notice = result.Times[i].Error
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check clock offset (down or degraded)
+	offset := best.offset
+	if offset < 0 {
+		offset = -offset
+	}
+	if c.DownOffset > 0 && offset > c.DownOffset {
+		result.Notice = fmt.Sprintf("clock offset %s exceeds %s", best.offset.Round(time.Millisecond), c.DownOffset)
+		result.Down = true
+		return result
+	}
+	if c.DegradedOffset > 0 && offset > c.DegradedOffset {
+		result.Notice = fmt.Sprintf("clock offset %s exceeds %s", best.offset.Round(time.Millisecond), c.DegradedOffset)
+		result.Degraded = true
+		return result
+	}
+
+	// Check stratum (degraded)
+	if c.MaxStratum > 0 && best.stratum > c.MaxStratum {
+		result.Notice = fmt.Sprintf("server stratum %d exceeds %d", best.stratum, c.MaxStratum)
+		result.Degraded = true
+		return result
+	}
+
+	// Check round trip time (degraded)
+	if c.ThresholdRTT > 0 {
+		stats := result.ComputeStats()
+		if stats.Median > c.ThresholdRTT {
+			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// metrics returns the offset and delay of r, in seconds, and
+// the stratum of the server. The offset thresholds are given
+// as Nagios ranges around zero.
+func (c Checker) metrics(r reply) []types.Metric {
+	offset := types.Metric{Label: "offset", Value: r.offset.Seconds(), Unit: "s"}
+	if c.DegradedOffset > 0 {
+		offset.Warn = fmt.Sprintf("%g:%g", -c.DegradedOffset.Seconds(), c.DegradedOffset.Seconds())
+	}
+	if c.DownOffset > 0 {
+		offset.Crit = fmt.Sprintf("%g:%g", -c.DownOffset.Seconds(), c.DownOffset.Seconds())
+	}
+	return []types.Metric{
+		offset,
+		{Label: "delay", Value: r.delay.Seconds(), Unit: "s"},
+		{Label: "stratum", Value: float64(r.stratum)},
+	}
+}
+
+// toNTP converts t to an NTP timestamp: seconds since 1900 in
+// the upper 32 bits and the fraction of a second in the lower.
+func toNTP(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	return secs<<32 | frac
+}
+
+// fromNTP converts the NTP timestamp ts to a time. Timestamps
+// in the first half of the era are assumed to be after 2036,
+// when the 32 bits of seconds wrap around.
+func fromNTP(ts uint64) time.Time {
+	secs := int64(ts >> 32)
+	if secs < math.MaxInt32 {
+		secs += 1 << 32
+	}
+	nsecs := int64((ts & 0xffffffff) * 1e9 >> 32)
+	return time.Unix(secs-ntpEpochOffset, nsecs)
+}
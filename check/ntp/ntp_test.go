@@ -0,0 +1,133 @@
+package ntp
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	for i, test := range []struct {
+		checker Checker
+		respond func(req []byte) []byte
+		status  string
+		notice  string
+	}{
+		{Checker{}, responder(0, 2, 0), "healthy", ""},
+		{Checker{DegradedOffset: time.Second, DownOffset: 10 * time.Second}, responder(100*time.Millisecond, 2, 0), "healthy", ""},
+		{Checker{DegradedOffset: time.Second, DownOffset: 10 * time.Second}, responder(5*time.Second, 2, 0), "degraded", "clock offset 5s exceeds 1s"},
+		{Checker{DegradedOffset: time.Second, DownOffset: 10 * time.Second}, responder(-30*time.Second, 2, 0), "down", "clock offset -30s exceeds 10s"},
+		{Checker{MaxStratum: 3}, responder(0, 4, 0), "degraded", "server stratum 4 exceeds 3"},
+		{Checker{}, responder(0, 2, 3), "down", "server clock is not synchronized"},
+		{Checker{}, responder(0, 0, 0), "down", "server sent kiss code RATE"},
+		{Checker{}, func([]byte) []byte { return make([]byte, packetSize) }, "down", "read udp"},
+		{Checker{ThresholdRTT: time.Nanosecond}, responder(0, 2, 0), "degraded", "median round trip time exceeded threshold"},
+	} {
+		ln := serve(t, test.respond)
+		defer ln.Close()
+
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = ln.LocalAddr().String()
+		tc.Timeout = 200 * time.Millisecond
+		tc.Attempts = 2
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+	}
+
+	tc := Checker{DegradedOffset: -time.Second}
+	if _, err := tc.Check(); err == nil {
+		t.Errorf("Expected an error for %+v, didn't get one", tc)
+	}
+}
+
+func TestOffset(t *testing.T) {
+	ln := serve(t, responder(-2*time.Second, 1, 0))
+	defer ln.Close()
+
+	tc := Checker{URL: ln.LocalAddr().String(), DegradedOffset: time.Second}
+	result, err := tc.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(result.Metrics), 3; got != want {
+		t.Fatalf("Expected %d metrics, got %v", want, result.Metrics)
+	}
+	offset := result.Metrics[0]
+	if offset.Label != "offset" || offset.Value > -1.9 || offset.Value < -2.1 {
+		t.Errorf("Expected an offset of about -2s, got %+v", offset)
+	}
+	if offset.Warn != "-1:1" {
+		t.Errorf("Expected warn range '-1:1', got '%s'", offset.Warn)
+	}
+	if stratum := result.Metrics[2]; stratum.Value != 1 {
+		t.Errorf("Expected stratum 1, got %+v", stratum)
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	for _, want := range []time.Time{
+		time.Date(2024, 2, 29, 12, 0, 0, 500000000, time.UTC),
+		time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		got := fromNTP(toNTP(want))
+		if d := got.Sub(want); d < -time.Microsecond || d > time.Microsecond {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+}
+
+// responder returns a reply function for a server whose clock
+// is ahead by offset, with the given stratum and leap
+// indicator. A stratum of 0 sends a RATE kiss code.
+func responder(offset time.Duration, stratum, leap byte) func([]byte) []byte {
+	return func(req []byte) []byte {
+		resp := make([]byte, packetSize)
+		resp[0] = leap<<6 | 4<<3 | 4
+		resp[1] = stratum
+		if stratum == 0 {
+			copy(resp[12:], "RATE")
+		} else {
+			copy(resp[12:], "GPS\x00")
+		}
+		copy(resp[24:32], req[40:48])
+		now := toNTP(time.Now().Add(offset))
+		binary.BigEndian.PutUint64(resp[32:], now)
+		binary.BigEndian.PutUint64(resp[40:], now)
+		return resp
+	}
+}
+
+// serve starts a UDP server on localhost that replies to each
+// request with respond.
+func serve(t *testing.T, respond func(req []byte) []byte) net.PacketConn {
+	ln, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := ln.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < packetSize {
+				continue
+			}
+			ln.WriteTo(respond(buf[:n]), addr)
+		}
+	}()
+	return ln
+}
@@ -0,0 +1,173 @@
+package pop3
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/mailcheck"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "pop3"
+
+// DefaultMaxMessages is how many of the newest messages are
+// searched for one matching Subject by default.
+const DefaultMaxMessages = 50
+
+// Checker implements a Checker for POP3 servers.
+//
+// Each attempt logs in and, if Subject is set, looks for a
+// message whose subject contains it among the newest
+// MaxMessages messages. If MaxAge is set, the Date header of
+// the message must be within it. Together with an smtp checker
+// sending test messages, this monitors mail delivery end to
+// end.
+type Checker struct {
+	mailcheck.Checker
+
+	// Subject is a string that the subject of a message
+	// in the mailbox must contain in order to be
+	// considered up.
+	Subject string `json:"subject,omitempty"`
+
+	// MaxAge is how recently the newest message matching
+	// Subject must have been sent in order to be
+	// considered up. If zero, any message matches.
+	MaxAge time.Duration `json:"max_age,omitempty"`
+
+	// MaxMessages is how many of the newest messages are
+	// searched for Subject. Default is DefaultMaxMessages.
+	MaxMessages int `json:"max_messages,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Username == "" {
+		return types.Result{}, errors.New("pop3: missing username")
+	}
+	if c.MaxAge > 0 && c.Subject == "" {
+		return types.Result{}, errors.New("pop3: max_age requires a subject")
+	}
+	if err := c.CheckCleartextAuth(); err != nil {
+		return types.Result{}, fmt.Errorf("pop3: %w", err)
+	}
+	if c.MaxMessages < 1 {
+		c.MaxMessages = DefaultMaxMessages
+	}
+	return c.Checker.Check(c.session)
+}
+
+// session logs in and looks for the message over conn.
+func (c Checker) session(conn net.Conn, tlsConfig *tls.Config) error {
+	text := textproto.NewConn(conn)
+	if _, err := reply(text); err != nil {
+		return fmt.Errorf("greeting: %w", err)
+	}
+	if tlsConfig != nil {
+		if _, err := cmd(text, "STLS"); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+		text = textproto.NewConn(tlsConn)
+	}
+	if _, err := cmd(text, "USER %s", c.Username); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	if _, err := cmd(text, "PASS %s", c.Password); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	if c.Subject != "" {
+		if err := c.findMessage(text); err != nil {
+			return err
+		}
+	}
+	_, err := cmd(text, "QUIT")
+	return err
+}
+
+// findMessage looks for the newest message matching c.Subject
+// and checks that it was sent within c.MaxAge.
+func (c Checker) findMessage(text *textproto.Conn) error {
+	stat, err := cmd(text, "STAT")
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+	count, err := strconv.Atoi(strings.Fields(stat + " ")[0])
+	if err != nil {
+		return fmt.Errorf("stat: unexpected reply: %s", stat)
+	}
+
+	for n := count; n > 0 && n > count-c.MaxMessages; n-- {
+		if _, err := cmd(text, "TOP %d 0", n); err != nil {
+			return fmt.Errorf("top: %w", err)
+		}
+		msg, err := mail.ReadMessage(bufio.NewReader(text.DotReader()))
+		if err != nil {
+			return fmt.Errorf("top: %w", err)
+		}
+		if !strings.Contains(msg.Header.Get("Subject"), c.Subject) {
+			continue
+		}
+		if c.MaxAge == 0 {
+			return nil
+		}
+		date, err := msg.Header.Date()
+		if err != nil {
+			return fmt.Errorf("message %d: %w", n, err)
+		}
+		if time.Since(date) <= c.MaxAge {
+			return nil
+		}
+	}
+	if c.MaxAge > 0 {
+		return fmt.Errorf("no message with subject '%s' in the last %s", c.Subject, c.MaxAge)
+	}
+	return fmt.Errorf("no message with subject '%s'", c.Subject)
+}
+
+// cmd sends a command and returns the text of its reply.
+func cmd(text *textproto.Conn, format string, args ...interface{}) (string, error) {
+	if err := text.PrintfLine(format, args...); err != nil {
+		return "", err
+	}
+	return reply(text)
+}
+
+// reply reads a reply and returns its text. An error is
+// returned unless the reply is +OK.
+func reply(text *textproto.Conn) (string, error) {
+	line, err := text.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return "", errors.New(line)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "+OK")), nil
+}
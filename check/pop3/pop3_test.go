@@ -0,0 +1,129 @@
+package pop3
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/mailcheck"
+)
+
+// message is a message in the fake mailbox.
+type message struct {
+	subject string
+	date    time.Time
+}
+
+func TestChecker(t *testing.T) {
+	ln := serve(t, []message{
+		{"Checkup test message", time.Now().Add(-2 * time.Hour)},
+		{"Weekly report", time.Now().Add(-time.Hour)},
+		{"Checkup test message", time.Now().Add(-3 * time.Minute)},
+		{"Newsletter", time.Now()},
+	})
+	defer ln.Close()
+
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+	}{
+		{Checker{}, "healthy", ""},
+		{Checker{Subject: "Checkup test"}, "healthy", ""},
+		{Checker{Subject: "Checkup test", MaxAge: 5 * time.Minute}, "healthy", ""},
+		{Checker{Subject: "Checkup test", MaxAge: 5 * time.Minute, MaxMessages: 1}, "down", "no message with subject 'Checkup test' in the last 5m0s"},
+		{Checker{Subject: "Weekly report", MaxAge: 5 * time.Minute}, "down", "no message with subject 'Weekly report' in the last 5m0s"},
+		{Checker{Subject: "Invoice"}, "down", "no message with subject 'Invoice'"},
+		{Checker{Checker: mailcheck.Checker{Password: "wrong"}}, "down", "login: -ERR"},
+		{Checker{Checker: mailcheck.Checker{StartTLS: true}}, "down", "starttls: -ERR"},
+		{Checker{Checker: mailcheck.Checker{ThresholdRTT: time.Nanosecond}}, "degraded", "median round trip time exceeded threshold"},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = ln.Addr().String()
+		tc.Username = "checkup"
+		if tc.Password == "" {
+			tc.Password = "secret"
+		}
+		tc.Attempts = 2
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+	}
+
+	for _, tc := range []Checker{
+		{Checker: mailcheck.Checker{URL: ln.Addr().String()}},
+		{Checker: mailcheck.Checker{URL: ln.Addr().String(), Username: "checkup"}, MaxAge: time.Minute},
+		{Checker: mailcheck.Checker{URL: "mail.example.com:110", Username: "checkup"}},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
+
+// serve starts a fake POP3 server with a mailbox holding
+// messages, which accepts the password "secret".
+func serve(t *testing.T, messages []message) net.Listener {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn, messages)
+		}
+	}()
+	return ln
+}
+
+// handle runs a POP3 session over conn.
+func handle(conn net.Conn, messages []message) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "+OK POP3 ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "USER":
+			fmt.Fprint(conn, "+OK\r\n")
+		case "PASS":
+			if fields[1] == "secret" {
+				fmt.Fprint(conn, "+OK logged in\r\n")
+			} else {
+				fmt.Fprint(conn, "-ERR [AUTH] invalid credentials\r\n")
+			}
+		case "STAT":
+			fmt.Fprintf(conn, "+OK %d %d\r\n", len(messages), 1000*len(messages))
+		case "TOP":
+			var n int
+			fmt.Sscanf(fields[1], "%d", &n)
+			msg := messages[n-1]
+			fmt.Fprintf(conn, "+OK\r\nSubject: %s\r\nDate: %s\r\n..dot-stuffed: yes\r\n\r\n.\r\n", msg.subject, msg.date.Format(time.RFC1123Z))
+		case "QUIT":
+			fmt.Fprint(conn, "+OK bye\r\n")
+			return
+		default:
+			fmt.Fprint(conn, "-ERR unknown command\r\n")
+		}
+	}
+}
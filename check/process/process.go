@@ -0,0 +1,182 @@
+package process
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/hostcheck"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "process"
+
+// procRoot is where processes are listed from; overridden in
+// tests.
+var procRoot = "/proc"
+
+// commLength is the length at which the kernel truncates
+// process names.
+const commLength = 15
+
+// Checker implements a Checker for the presence of processes
+// on the local host, read from /proc. It only works on Linux.
+//
+// The number of matching processes is reported as the "count"
+// metric of the result.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// ProcessName is the name of the processes to count,
+	// such as "nginx". Note that the kernel truncates
+	// process names to 15 characters.
+	ProcessName string `json:"process_name,omitempty"`
+
+	// CmdlineRegex is a regular expression that the
+	// command line of the processes to count, with its
+	// arguments separated by spaces, must match. If both
+	// ProcessName and CmdlineRegex are set, processes
+	// must match both.
+	CmdlineRegex string `json:"cmdline_regex,omitempty"`
+
+	// MinCount is the minimum number of matching
+	// processes. Default is 1.
+	MinCount int `json:"min_count,omitempty"`
+
+	// MaxCount is the maximum number of matching
+	// processes. Zero means there is no maximum.
+	MaxCount int `json:"max_count,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.ProcessName == "" && c.CmdlineRegex == "" {
+		return types.Result{}, errors.New("process: missing process_name or cmdline_regex")
+	}
+	var re *regexp.Regexp
+	if c.CmdlineRegex != "" {
+		var err error
+		if re, err = regexp.Compile(c.CmdlineRegex); err != nil {
+			return types.Result{}, err
+		}
+	}
+	if c.MinCount < 1 {
+		c.MinCount = 1
+	}
+	if c.MaxCount > 0 && c.MaxCount < c.MinCount {
+		return types.Result{}, fmt.Errorf("process: max_count %d is less than min_count %d", c.MaxCount, c.MinCount)
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.endpoint()
+	result.Tags = c.Tags
+
+	start := time.Now()
+	var eval hostcheck.Evaluation
+	count, err := c.count(re)
+	if err != nil {
+		eval.Down("%v", err)
+	} else {
+		eval.Metric("count", float64(count), "")
+		switch {
+		case count < c.MinCount:
+			eval.Down("found %d matching processes, expected at least %d", count, c.MinCount)
+		case c.MaxCount > 0 && count > c.MaxCount:
+			eval.Down("found %d matching processes, expected at most %d", count, c.MaxCount)
+		}
+	}
+	result.Times = types.Attempts{{RTT: time.Since(start)}}
+
+	return eval.Conclude(result), nil
+}
+
+// endpoint describes the processes that c matches.
+func (c Checker) endpoint() string {
+	var parts []string
+	if c.ProcessName != "" {
+		parts = append(parts, c.ProcessName)
+	}
+	if c.CmdlineRegex != "" {
+		parts = append(parts, "/"+c.CmdlineRegex+"/")
+	}
+	return strings.Join(parts, " ")
+}
+
+// count returns the number of processes that match c, other
+// than this one.
+func (c Checker) count(re *regexp.Regexp) (int, error) {
+	entries, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return 0, err
+	}
+	self := os.Getpid()
+	name := c.ProcessName
+	if len(name) > commLength {
+		name = name[:commLength]
+	}
+
+	count := 0
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		dir := filepath.Join(procRoot, entry.Name())
+		if name != "" {
+			comm, err := ioutil.ReadFile(filepath.Join(dir, "comm"))
+			if err != nil || strings.TrimSuffix(string(comm), "\n") != name {
+				// the process may have exited
+				continue
+			}
+		}
+		if re != nil {
+			cmdline, err := ioutil.ReadFile(filepath.Join(dir, "cmdline"))
+			if err != nil {
+				continue
+			}
+			args := string(bytes.TrimRight(bytes.Replace(cmdline, []byte{0}, []byte{' '}, -1), " "))
+			if !re.MatchString(args) {
+				continue
+			}
+		}
+		count++
+	}
+	return count, nil
+}
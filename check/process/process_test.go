@@ -0,0 +1,81 @@
+package process
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChecker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup-process")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(root string) { procRoot = root }(procRoot)
+	procRoot = dir
+
+	for pid, p := range map[string][2]string{
+		"1":   {"systemd", "/sbin/init\x00splash\x00"},
+		"100": {"nginx", "nginx: master process /usr/sbin/nginx\x00"},
+		"101": {"nginx", "nginx: worker process\x00"},
+		"102": {"nginx", "nginx: worker process\x00"},
+		"200": {"java", "/usr/bin/java\x00-jar\x00/opt/app/app.jar\x00"},
+		"300": {"node_exporter_v", "/usr/local/bin/node_exporter_very_long\x00"},
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, pid), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, pid, "comm"), []byte(p[0]+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, pid, "cmdline"), []byte(p[1]), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// not a process
+	if err := os.MkdirAll(filepath.Join(dir, "sys"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+	}{
+		{Checker{ProcessName: "nginx"}, "healthy", ""},
+		{Checker{ProcessName: "nginx", MinCount: 3, MaxCount: 3}, "healthy", ""},
+		{Checker{ProcessName: "nginx", MinCount: 4}, "down", "found 3 matching processes, expected at least 4"},
+		{Checker{ProcessName: "nginx", MaxCount: 2}, "down", "found 3 matching processes, expected at most 2"},
+		{Checker{ProcessName: "nginx", CmdlineRegex: "master"}, "healthy", ""},
+		{Checker{CmdlineRegex: `java -jar .*app\.jar`}, "healthy", ""},
+		{Checker{ProcessName: "node_exporter_very_long"}, "healthy", ""},
+		{Checker{ProcessName: "postgres"}, "down", "found 0 matching processes, expected at least 1"},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+	}
+
+	for _, tc := range []Checker{
+		{},
+		{CmdlineRegex: "("},
+		{ProcessName: "nginx", MinCount: 3, MaxCount: 2},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/dbcheck"
+)
+
+func TestChecker(t *testing.T) {
+	ln := serve(t)
+	defer ln.Close()
+	addr := ln.Addr().String()
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+	}{
+		{Checker{}, "healthy", ""},
+		{Checker{Checker: dbcheck.Checker{Query: "GET greeting", Expect: "= hello"}}, "healthy", ""},
+		{Checker{Checker: dbcheck.Checker{Query: "GET greeting", Expect: "!= hello"}}, "down", "query returned hello, expected != hello"},
+		{Checker{Checker: dbcheck.Checker{Query: "GET missing", Expect: "= NULL"}}, "healthy", ""},
+		{Checker{Checker: dbcheck.Checker{Query: "INFO replication", Expect: "< 60", DegradedIf: "> 10"}, Field: "master_last_io_seconds_ago"}, "degraded", "query returned 12, degraded if > 10"},
+		{Checker{Checker: dbcheck.Checker{Query: "INFO replication", Expect: "< 10"}, Field: "master_last_io_seconds_ago"}, "down", "query returned 12, expected < 10"},
+		{Checker{Checker: dbcheck.Checker{Query: "INFO replication"}, Field: "missing"}, "down", "query: field missing not found in reply"},
+		{Checker{Checker: dbcheck.Checker{Query: "FLUSHALL"}}, "down", "query: ERR unknown command"},
+		{Checker{Checker: dbcheck.Checker{Query: "GET huge"}}, "down", "query: bulk string too long"},
+		{Checker{Checker: dbcheck.Checker{Query: "LRANGE list 0 -1"}}, "down", "query: array too long"},
+		{Checker{Checker: dbcheck.Checker{Query: "HGETALL hash"}}, "down", "query: arrays nested more than 8 deep"},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.DSN = "redis://:secret@" + addr + "/1"
+		tc.Attempts = 2
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+		if got, want := result.Endpoint, "redis://:xxxxx@"+addr+"/1"; got != want {
+			t.Errorf("Test %d: Expected endpoint %s, got %s", i, want, got)
+		}
+	}
+
+	for i, test := range []struct {
+		dsn    string
+		notice string
+	}{
+		{"redis://:wrong@" + addr, "connect: auth: WRONGPASS"},
+		{"redis://" + addr + "/x", "connect: invalid database: x"},
+	} {
+		tc := Checker{Checker: dbcheck.Checker{Name: "Test", DSN: test.dsn, Timeout: time.Second}}
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if !result.Down || !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected down with notice '%s', got %s: '%s'", i, test.notice, result.Status(), result.Notice)
+		}
+	}
+
+	for _, tc := range []Checker{
+		{Checker: dbcheck.Checker{DSN: "http://" + addr}},
+		{Checker: dbcheck.Checker{DSN: "redis://" + addr}, Field: "role"},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
+
+// serve starts a fake Redis server that requires the password
+// "secret", and returns its listener.
+func serve(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handle(c)
+		}
+	}()
+	return ln
+}
+
+// handle replies to the commands sent over c.
+func handle(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "AUTH":
+			if args[len(args)-1] == "secret" {
+				reply = "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case "SELECT":
+			reply = "+OK\r\n"
+		case "PING":
+			reply = "+PONG\r\n"
+		case "GET":
+			switch args[1] {
+			case "greeting":
+				reply = "$5\r\nhello\r\n"
+			case "huge":
+				reply = "$8388608\r\n"
+			default:
+				reply = "$-1\r\n"
+			}
+		case "LRANGE":
+			reply = "*100000000\r\n"
+		case "HGETALL":
+			reply = strings.Repeat("*1\r\n", 100)
+		case "INFO":
+			info := "# Replication\r\nrole:slave\r\nmaster_last_io_seconds_ago:12\r\n"
+			reply = fmt.Sprintf("$%d\r\n%s\r\n", len(info), info)
+		default:
+			reply = "-ERR unknown command '" + args[0] + "'\r\n"
+		}
+		if _, err := io.WriteString(c, reply); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimRight(arg, "\r\n")
+	}
+	return args, nil
+}
@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits on the replies the server may send, so that a
+// misbehaving server can't make the check allocate or recurse
+// without bound. Health check queries have small replies.
+const (
+	maxBulkLen    = 4 << 20
+	maxArrayLen   = 1 << 16
+	maxArrayDepth = 8
+)
+
+// conn is a connection to a Redis server speaking RESP, the
+// Redis serialization protocol.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// do sends the command made of args and returns its reply.
+// Error replies are returned as errors, and arrays are joined
+// by newlines.
+func (c *conn) do(ctx context.Context, args ...string) (string, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Time{}
+	}
+	if err := c.SetDeadline(deadline); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c, b.String()); err != nil {
+		return "", err
+	}
+	return c.reply(0)
+}
+
+// reply reads one reply from the server, nested depth arrays
+// deep.
+func (c *conn) reply(depth int) (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", errors.New(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid bulk string length: %s", line)
+		}
+		if n < 0 {
+			return "NULL", nil
+		}
+		if n > maxBulkLen {
+			return "", fmt.Errorf("bulk string too long: %d bytes", n)
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid array length: %s", line)
+		}
+		if n < 0 {
+			return "NULL", nil
+		}
+		if n > maxArrayLen {
+			return "", fmt.Errorf("array too long: %d elements", n)
+		}
+		if depth >= maxArrayDepth {
+			return "", fmt.Errorf("arrays nested more than %d deep", maxArrayDepth)
+		}
+		elems := make([]string, n)
+		for i := range elems {
+			if elems[i], err = c.reply(depth + 1); err != nil {
+				return "", err
+			}
+		}
+		return strings.Join(elems, "\n"), nil
+	}
+	return "", fmt.Errorf("unexpected reply: %s", line)
+}
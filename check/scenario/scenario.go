@@ -0,0 +1,257 @@
+package scenario
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "scenario"
+
+// Checker implements a Checker for multi-step HTTP
+// transactions, such as logging in, fetching a page and
+// logging out.
+//
+// Each attempt runs the steps in order with a fresh cookie
+// jar and the initial Variables, and stops at the first step
+// that fails, which is named in the notice. Steps may extract
+// variables from their responses for later steps to use. The
+// median time of each step is reported as the "step:{name}"
+// metric of the result.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the base URL that the URLs of steps are
+	// resolved against, if set.
+	URL string `json:"endpoint_url,omitempty"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Variables are the initial variables of each attempt.
+	Variables map[string]string `json:"variables,omitempty"`
+
+	// Steps are the requests to make, in order.
+	Steps []Step `json:"steps"`
+
+	// TLSSkipVerify controls whether to skip server TLS
+	// certificate validation or not.
+	TLSSkipVerify bool `json:"tls_skip_verify,omitempty"`
+
+	// Timeout is the maximum time each step may take.
+	// Default is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. If non-zero and a
+	// request takes longer than ThresholdRTT, the
+	// endpoint will be considered unhealthy. Note that
+	// this duration includes all the steps and any
+	// in-between network latency.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many times the client will run the
+	// scenario in a single check.
+	Attempts int `json:"attempts,omitempty"`
+
+	// AttemptSpacing spaces out each attempt in a check
+	// by this duration to avoid hitting a remote too
+	// quickly in succession. By default, no waiting
+	// occurs between attempts.
+	AttemptSpacing time.Duration `json:"attempt_spacing,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if len(c.Steps) == 0 {
+		return types.Result{}, errors.New("scenario: missing steps")
+	}
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	var base *url.URL
+	if c.URL != "" {
+		var err error
+		if base, err = url.Parse(c.URL); err != nil {
+			return types.Result{}, fmt.Errorf("scenario: %v", err)
+		}
+	}
+	steps := make([]step, len(c.Steps))
+	for i, s := range c.Steps {
+		var err error
+		if steps[i], err = compile(i, s); err != nil {
+			return types.Result{}, err
+		}
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+	if result.Endpoint == "" {
+		result.Endpoint = c.Steps[0].URL
+	}
+	result.Tags = c.Tags
+	var durations [][]time.Duration
+	result.Times, durations = c.doChecks(steps, base)
+	result.Metrics = metrics(steps, durations)
+
+	return c.conclude(result, steps, durations), nil
+}
+
+// doChecks executes the checks and returns each attempt, along
+// with the durations of each step that succeeded, by step.
+func (c Checker) doChecks(steps []step, base *url.URL) (types.Attempts, [][]time.Duration) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: c.Timeout,
+		}).DialContext,
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: c.TLSSkipVerify},
+		TLSHandshakeTimeout: c.Timeout,
+		DisableKeepAlives:   true,
+	}
+	defer transport.CloseIdleConnections()
+
+	checks := make(types.Attempts, c.Attempts)
+	durations := make([][]time.Duration, len(steps))
+	for i := 0; i < c.Attempts; i++ {
+		start := time.Now()
+		err := c.attempt(transport, steps, base, durations)
+		checks[i].RTT = time.Since(start)
+		if err != nil {
+			checks[i].Error = err.Error()
+		}
+		if c.AttemptSpacing > 0 {
+			time.Sleep(c.AttemptSpacing)
+		}
+	}
+	return checks, durations
+}
+
+// attempt runs steps in order with a new cookie jar, and
+// appends the duration of each that succeeds to durations.
+// It stops at the first step that fails.
+func (c Checker) attempt(transport http.RoundTripper, steps []step, base *url.URL, durations [][]time.Duration) error {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Transport: transport, Jar: jar, Timeout: c.Timeout}
+	vars := make(map[string]string, len(c.Variables))
+	for name, value := range c.Variables {
+		vars[name] = value
+	}
+
+	for i, s := range steps {
+		start := time.Now()
+		err := s.run(client, base, vars)
+		if err != nil {
+			return fmt.Errorf("step '%s' failed: %v", s.Name, err)
+		}
+		durations[i] = append(durations[i], time.Since(start))
+	}
+	return nil
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It detects degraded (slow step or high-latency) scenarios
+// and makes the conclusion about the result's status.
+func (c Checker) conclude(result types.Result, steps []step, durations [][]time.Duration) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Notice = result.Times[i].Error
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check time of each step (degraded)
+	for i, s := range steps {
+		if s.ThresholdRTT > 0 {
+			if d := median(durations[i]); d > s.ThresholdRTT {
+				result.Notice = fmt.Sprintf("step '%s' took %s, more than %s", s.Name, d, s.ThresholdRTT)
+				result.Degraded = true
+				return result
+			}
+		}
+	}
+
+	// Check round trip time (degraded)
+	if c.ThresholdRTT > 0 {
+		stats := result.ComputeStats()
+		if stats.Median > c.ThresholdRTT {
+			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// metrics returns the median time of each step that succeeded
+// at least once, in seconds.
+func metrics(steps []step, durations [][]time.Duration) []types.Metric {
+	var metrics []types.Metric
+	for i, s := range steps {
+		if len(durations[i]) == 0 {
+			continue
+		}
+		m := types.Metric{Label: "step:" + s.Name, Value: median(durations[i]).Seconds(), Unit: "s"}
+		if s.ThresholdRTT > 0 {
+			m.Warn = fmt.Sprint(s.ThresholdRTT.Seconds())
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// median returns the median of durations.
+func median(durations []time.Duration) time.Duration {
+	attempts := make(types.Attempts, len(durations))
+	for i, d := range durations {
+		attempts[i].RTT = d
+	}
+	return types.Result{Times: attempts}.ComputeStats().Median
+}
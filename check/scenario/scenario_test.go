@@ -0,0 +1,153 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		var creds struct{ User, Password string }
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&creds) != nil || creds.Password != "hunter2" {
+			http.Error(w, `{"error": "invalid credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: creds.User, Path: "/"})
+		w.Header().Set("X-Request-Id", "req-42")
+		fmt.Fprintf(w, `{"token": "tok-%s", "user": {"name": "%s"}}`, creds.User, creds.User)
+	})
+	mux.HandleFunc("/dashboard", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil || r.Header.Get("Authorization") != "Bearer tok-"+cookie.Value {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		fmt.Fprintf(w, `<h1>Welcome %s</h1><input name="csrf" value="csrf-%s">`, cookie.Value, cookie.Value)
+	})
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("csrf") != "csrf-alice" {
+			http.Error(w, "bad csrf token", http.StatusForbidden)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Path: "/", MaxAge: -1})
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err == nil {
+			http.Error(w, "still logged in", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("Goodbye"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	os.Setenv("CHECKUP_TEST_PASSWORD", "hunter2")
+	defer os.Unsetenv("CHECKUP_TEST_PASSWORD")
+
+	login := Step{
+		Name:    "login",
+		Method:  "POST",
+		URL:     "/login",
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Body:    `{"user": "{{.user}}", "password": "{{env "CHECKUP_TEST_PASSWORD"}}"}`,
+		Extract: map[string]Extraction{
+			"token":   {JSONPath: "$.token"},
+			"request": {Header: "X-Request-Id"},
+		},
+		JSONPath:       "$.user.name",
+		JSONPathEquals: "alice",
+	}
+	dashboard := Step{
+		Name:        "dashboard",
+		URL:         "/dashboard?request={{.request}}",
+		Headers:     map[string]string{"Authorization": "Bearer {{.token}}"},
+		MustContain: "Welcome alice",
+		Extract:     map[string]Extraction{"csrf": {Regex: `name="csrf" value="([^"]+)"`}},
+	}
+	logout := Step{
+		Name:            "logout",
+		Method:          "POST",
+		URL:             "/logout",
+		Headers:         map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		Body:            "csrf={{.csrf}}",
+		FollowRedirects: true,
+		MustContain:     "Goodbye",
+	}
+	variables := map[string]string{"user": "alice"}
+
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+		metrics int
+	}{
+		{Checker{Steps: []Step{login, dashboard, logout}}, "healthy", "", 3},
+		{Checker{Steps: []Step{dashboard}}, "down", "step 'dashboard' failed: template: url:1:21: executing \"url\" at <.request>: map has no entry for key \"request\"", 0},
+		{Checker{Steps: []Step{login, {URL: "/dashboard", MustContain: "Welcome"}}}, "down", "step 'step 2' failed: response status 302 Found", 1},
+		{Checker{Steps: []Step{login, {URL: "/dashboard", FollowRedirects: true, MustContain: "Welcome"}}}, "down", "step 'step 2' failed: response status 401 Unauthorized", 1},
+		{Checker{Steps: []Step{login, dashboard, {URL: "/logout", Method: "POST", Body: "csrf=wrong", UpStatus: 303}}}, "down", "step 'step 3' failed: response status 403 Forbidden", 2},
+		{Checker{Steps: []Step{login, dashboard, {URL: "/", UpStatus: 500}}}, "healthy", "", 3},
+		{Checker{Steps: []Step{login, {URL: "/dashboard", Extract: map[string]Extraction{"x": {Header: "X-Missing"}}}}}, "down", "step 'step 2' failed: response status 302", 1},
+		{Checker{Steps: []Step{login, {URL: "/login", Method: "POST", Body: `{"user": "alice", "password": "hunter2"}`, Extract: map[string]Extraction{"x": {Header: "X-Missing"}}}}}, "down", "step 'step 2' failed: extracting x: header X-Missing not found", 1},
+		{Checker{Steps: []Step{{URL: "/{{.missing}}"}}}, "down", "step 'step 1' failed: template: url", 0},
+		{Checker{Steps: []Step{login, {Name: "json", URL: "/login", Method: "POST", Body: `{"user": "bob", "password": "hunter2"}`, JSONPath: "$.user.name", JSONPathEquals: "alice"}}}, "down", "step 'json' failed: $.user.name is 'bob', expected 'alice'", 1},
+		{Checker{Steps: []Step{{Name: "slow", URL: "/slow", ThresholdRTT: 10 * time.Millisecond}}}, "degraded", "step 'slow' took", 1},
+		{Checker{Steps: []Step{{URL: "/slow"}}, ThresholdRTT: 10 * time.Millisecond}, "degraded", "median round trip time exceeded threshold", 1},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = srv.URL
+		tc.Variables = variables
+		tc.Attempts = 2
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+		if got, want := len(result.Metrics), test.metrics; got != want {
+			t.Errorf("Test %d: Expected %d metrics, got %v", i, want, result.Metrics)
+		}
+	}
+
+	// steps may use absolute URLs without a base URL
+	tc := Checker{Name: "Test", Steps: []Step{{URL: srv.URL + "/"}}}
+	result, err := tc.Check()
+	if err != nil {
+		t.Errorf("Didn't expect an error: %v", err)
+	} else if !result.Healthy {
+		t.Errorf("Expected result.Healthy=true, got %s: %s", result.Status(), result.Notice)
+	}
+
+	for _, tc := range []Checker{
+		{},
+		{Steps: []Step{{}}},
+		{Steps: []Step{{URL: "/{{"}}},
+		{Steps: []Step{{URL: "/", JSONPath: "$."}}},
+		{Steps: []Step{{URL: "/", JSONPathEquals: "x"}}},
+		{Steps: []Step{{URL: "/", Extract: map[string]Extraction{"x": {}}}}},
+		{Steps: []Step{{URL: "/", Extract: map[string]Extraction{"x": {Regex: "(", Header: "X"}}}}},
+		{Steps: []Step{{URL: "/", Extract: map[string]Extraction{"x": {Regex: "("}}}}},
+		{URL: ":", Steps: []Step{{URL: "/"}}},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package scenario
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/jsonpath"
+)
+
+// MaxBodySize is the maximum number of bytes of a response
+// body that are read for assertions and extractions.
+const MaxBodySize = 10 << 20
+
+// Step is one HTTP request of a scenario. Its URL, headers and
+// body are templates, in which the variables of the scenario
+// are available as {{.name}} and environment variables as
+// {{env "NAME"}}.
+type Step struct {
+	// Name identifies the step in notices and metrics.
+	// Default is "step N", where N counts from 1.
+	Name string `json:"name,omitempty"`
+
+	// Method is the HTTP method. Default is GET.
+	Method string `json:"method,omitempty"`
+
+	// URL is the URL to request, which may be relative to
+	// the URL of the scenario.
+	URL string `json:"url"`
+
+	// Headers are the headers to add to the request.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Body is the body of the request.
+	Body string `json:"body,omitempty"`
+
+	// FollowRedirects controls whether to follow redirects
+	// and assert on the final response, rather than the
+	// redirect itself.
+	FollowRedirects bool `json:"follow_redirects,omitempty"`
+
+	// UpStatus is the HTTP status code expected. Default
+	// is any of 200-204.
+	UpStatus int `json:"up_status,omitempty"`
+
+	// MustContain is a string that the response body
+	// must contain.
+	MustContain string `json:"must_contain,omitempty"`
+
+	// MustNotContain is a string that the response body
+	// must NOT contain.
+	MustNotContain string `json:"must_not_contain,omitempty"`
+
+	// JSONPath is a path, such as $.status, that must
+	// exist in the JSON response body.
+	JSONPath string `json:"json_path,omitempty"`
+
+	// JSONPathEquals is the value that JSONPath must
+	// have, if set. Values other than strings are
+	// compared in their JSON form, such as 42 or true.
+	JSONPathEquals string `json:"json_path_equals,omitempty"`
+
+	// Extract maps the names of variables to set to where
+	// their values are extracted from in the response.
+	Extract map[string]Extraction `json:"extract,omitempty"`
+
+	// ThresholdRTT is the maximum time the step may take
+	// for the scenario to be healthy. If non-zero and the
+	// median time of the step exceeds it, the scenario is
+	// considered degraded.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+}
+
+// Extraction describes where to extract a variable from in a
+// response. Exactly one of its fields must be set.
+type Extraction struct {
+	// JSONPath is a path, such as $.token, in the JSON
+	// response body.
+	JSONPath string `json:"json_path,omitempty"`
+
+	// Regex is a regular expression matched against the
+	// response body. The value is its first submatch, or
+	// the whole match if it has no groups.
+	Regex string `json:"regex,omitempty"`
+
+	// Header is the name of a response header.
+	Header string `json:"header,omitempty"`
+}
+
+// step is a Step with its templates and expressions parsed.
+type step struct {
+	Step
+	url, body *template.Template
+	headers   map[string]*template.Template
+	path      *jsonpath.Path
+	extract   map[string]extractor
+}
+
+// extractor extracts the value of a variable from a response.
+type extractor func(resp *http.Response, body []byte) (string, error)
+
+// funcs are the functions available in templates.
+var funcs = template.FuncMap{"env": os.Getenv}
+
+// compile parses the templates and expressions of s, which is
+// the step at index i.
+func compile(i int, s Step) (step, error) {
+	if s.Name == "" {
+		s.Name = fmt.Sprintf("step %d", i+1)
+	}
+	if s.Method == "" {
+		s.Method = http.MethodGet
+	}
+	if s.URL == "" {
+		return step{}, fmt.Errorf("scenario: %s: missing url", s.Name)
+	}
+	parse := func(name, text string) (*template.Template, error) {
+		t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("scenario: %s: %v", s.Name, err)
+		}
+		return t, nil
+	}
+
+	compiled := step{Step: s, headers: make(map[string]*template.Template), extract: make(map[string]extractor)}
+	var err error
+	if compiled.url, err = parse("url", s.URL); err != nil {
+		return step{}, err
+	}
+	if compiled.body, err = parse("body", s.Body); err != nil {
+		return step{}, err
+	}
+	for key, value := range s.Headers {
+		if compiled.headers[key], err = parse(key, value); err != nil {
+			return step{}, err
+		}
+	}
+	if s.JSONPath != "" {
+		p, err := jsonpath.Parse(s.JSONPath)
+		if err != nil {
+			return step{}, fmt.Errorf("scenario: %s: %v", s.Name, err)
+		}
+		compiled.path = &p
+	} else if s.JSONPathEquals != "" {
+		return step{}, fmt.Errorf("scenario: %s: json_path_equals requires json_path", s.Name)
+	}
+	for name, e := range s.Extract {
+		if compiled.extract[name], err = e.compile(); err != nil {
+			return step{}, fmt.Errorf("scenario: %s: extracting %s: %v", s.Name, name, err)
+		}
+	}
+	return compiled, nil
+}
+
+// compile returns the extractor described by e.
+func (e Extraction) compile() (extractor, error) {
+	set := 0
+	for _, field := range []string{e.JSONPath, e.Regex, e.Header} {
+		if field != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("exactly one of json_path, regex and header must be set")
+	}
+
+	switch {
+	case e.JSONPath != "":
+		p, err := jsonpath.Parse(e.JSONPath)
+		if err != nil {
+			return nil, err
+		}
+		return func(_ *http.Response, body []byte) (string, error) {
+			return p.Lookup(body)
+		}, nil
+	case e.Regex != "":
+		re, err := regexp.Compile(e.Regex)
+		if err != nil {
+			return nil, err
+		}
+		return func(_ *http.Response, body []byte) (string, error) {
+			m := re.FindSubmatch(body)
+			if m == nil {
+				return "", fmt.Errorf("/%s/ did not match", re)
+			}
+			if len(m) > 1 {
+				return string(m[1]), nil
+			}
+			return string(m[0]), nil
+		}, nil
+	default:
+		return func(resp *http.Response, _ []byte) (string, error) {
+			value := resp.Header.Get(e.Header)
+			if value == "" {
+				return "", fmt.Errorf("header %s not found", e.Header)
+			}
+			return value, nil
+		}, nil
+	}
+}
+
+// request renders the request of s with vars, resolving its
+// URL against base, if set.
+func (s step) request(base *url.URL, vars map[string]string) (*http.Request, error) {
+	render := func(t *template.Template) (string, error) {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, vars); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	rawURL, err := render(s.url)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	body, err := render(s.body)
+	if err != nil {
+		return nil, err
+	}
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(s.Method, u.String(), r)
+	if err != nil {
+		return nil, err
+	}
+	for key, t := range s.headers {
+		value, err := render(t)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(key, value)
+		// net/http has special Host field which we'll fill out
+		if strings.ToLower(key) == "host" {
+			req.Host = value
+		}
+	}
+	return req, nil
+}
+
+// run performs s with client, asserts on its response, and
+// stores the variables it extracts in vars.
+func (s step) run(client *http.Client, base *url.URL, vars map[string]string) error {
+	req, err := s.request(base, vars)
+	if err != nil {
+		return err
+	}
+	if !s.FollowRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	} else {
+		client.CheckRedirect = nil
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxBodySize))
+	if err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+
+	if err := s.checkDown(resp, body); err != nil {
+		return err
+	}
+	for name, extract := range s.extract {
+		value, err := extract(resp, body)
+		if err != nil {
+			return fmt.Errorf("extracting %s: %v", name, err)
+		}
+		vars[name] = value
+	}
+	return nil
+}
+
+// checkDown checks the assertions of s on resp and its body.
+// It returns a non-nil error if any fails.
+func (s step) checkDown(resp *http.Response, body []byte) error {
+	if s.UpStatus > 0 {
+		if resp.StatusCode != s.UpStatus {
+			return fmt.Errorf("response status %s", resp.Status)
+		}
+	} else if resp.StatusCode < http.StatusOK || resp.StatusCode > http.StatusNoContent {
+		return fmt.Errorf("response status %s", resp.Status)
+	}
+	if s.MustContain != "" && !bytes.Contains(body, []byte(s.MustContain)) {
+		return fmt.Errorf("response does not contain '%s'", s.MustContain)
+	}
+	if s.MustNotContain != "" && bytes.Contains(body, []byte(s.MustNotContain)) {
+		return fmt.Errorf("response contains '%s'", s.MustNotContain)
+	}
+	if s.path != nil {
+		value, err := s.path.Lookup(body)
+		if err != nil {
+			return err
+		}
+		if s.JSONPathEquals != "" && value != s.JSONPathEquals {
+			return fmt.Errorf("%s is '%s', expected '%s'", s.path, value, s.JSONPathEquals)
+		}
+	}
+	return nil
+}
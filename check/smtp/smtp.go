@@ -0,0 +1,142 @@
+package smtp
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/mailcheck"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "smtp"
+
+// DefaultSubject is the subject of test messages.
+const DefaultSubject = "Checkup test message"
+
+// Checker implements a Checker for SMTP servers.
+//
+// Each attempt greets the server with EHLO, upgrades the
+// connection with STARTTLS if configured, and authenticates
+// with AUTH PLAIN if a username is set. If To is set, a test
+// message is then sent to it, which an imap or pop3 checker
+// on the receiving end can look for to monitor delivery end
+// to end.
+type Checker struct {
+	mailcheck.Checker
+
+	// HeloName is the name to greet the server with.
+	// Default is "localhost".
+	HeloName string `json:"helo_name,omitempty"`
+
+	// From is the sender address of the test message.
+	From string `json:"from,omitempty"`
+
+	// To is the address to send a test message to,
+	// typically a sink. If empty, no message is sent.
+	To string `json:"to,omitempty"`
+
+	// Subject is the subject of the test message.
+	// Default is DefaultSubject.
+	Subject string `json:"subject,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.To != "" && c.From == "" {
+		return types.Result{}, errors.New("smtp: a test message requires from")
+	}
+	if c.HeloName == "" {
+		c.HeloName = "localhost"
+	}
+	if c.Subject == "" {
+		c.Subject = DefaultSubject
+	}
+	return c.Checker.Check(c.session)
+}
+
+// session greets the server, authenticates and sends the test
+// message over conn.
+func (c Checker) session(conn net.Conn, tlsConfig *tls.Config) error {
+	host, _, _ := net.SplitHostPort(c.URL)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Hello(c.HeloName); err != nil {
+		return fmt.Errorf("ehlo: %w", err)
+	}
+	if tlsConfig != nil {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if c.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("server does not support AUTH")
+		}
+		if err := client.Auth(smtp.PlainAuth("", c.Username, c.Password, host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	if c.To != "" {
+		if err := c.send(client); err != nil {
+			return err
+		}
+	}
+	return client.Quit()
+}
+
+// send sends the test message with client.
+func (c Checker) send(client *smtp.Client) error {
+	if err := client.Mail(c.From); err != nil {
+		return fmt.Errorf("mail from: %w", err)
+	}
+	if err := client.Rcpt(c.To); err != nil {
+		return fmt.Errorf("rcpt to: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+	now := time.Now()
+	headers := []string{
+		"From: " + c.From,
+		"To: " + c.To,
+		"Subject: " + c.Subject,
+		"Date: " + now.Format(time.RFC1123Z),
+		fmt.Sprintf("Message-ID: <%d.checkup@%s>", now.UnixNano(), c.HeloName),
+	}
+	msg := strings.Join(headers, "\r\n") + "\r\n\r\nSent by checkup to monitor mail delivery.\r\n"
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+	return nil
+}
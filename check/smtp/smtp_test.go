@@ -0,0 +1,173 @@
+package smtp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/checkup/check/internal/mailcheck"
+)
+
+func TestChecker(t *testing.T) {
+	// borrow the test certificate of httptest
+	tlsSrv := httptest.NewTLSServer(nil)
+	cert := tlsSrv.TLS.Certificates[0]
+	tlsSrv.Close()
+
+	messages := make(chan string, 10)
+	ln := serve(t, &tls.Config{Certificates: []tls.Certificate{cert}}, messages)
+	defer ln.Close()
+	plainLn := serve(t, nil, messages)
+	defer plainLn.Close()
+
+	for i, test := range []struct {
+		checker Checker
+		addr    string
+		status  string
+		notice  string
+	}{
+		{Checker{}, ln.Addr().String(), "healthy", ""},
+		{Checker{Checker: mailcheck.Checker{Username: "checkup", Password: "secret"}}, ln.Addr().String(), "healthy", ""},
+		{Checker{Checker: mailcheck.Checker{Username: "checkup", Password: "wrong"}}, ln.Addr().String(), "down", "auth: 535"},
+		{Checker{Checker: mailcheck.Checker{StartTLS: true, TLSSkipVerify: true, Username: "checkup", Password: "secret"}}, ln.Addr().String(), "healthy", ""},
+		{Checker{Checker: mailcheck.Checker{StartTLS: true}}, ln.Addr().String(), "down", "starttls: tls: failed to verify certificate"},
+		{Checker{Checker: mailcheck.Checker{StartTLS: true}}, plainLn.Addr().String(), "down", "server does not support STARTTLS"},
+		{Checker{From: "checkup@example.com", To: "unknown@example.com"}, ln.Addr().String(), "down", "rcpt to: 550"},
+		{Checker{Checker: mailcheck.Checker{ThresholdRTT: time.Nanosecond}}, ln.Addr().String(), "degraded", "median round trip time exceeded threshold"},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = test.addr
+		tc.Attempts = 2
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+	}
+
+	// a test message is delivered to the sink
+	tc := Checker{From: "checkup@example.com", To: "sink@example.com", Subject: "Delivery test"}
+	tc.URL = ln.Addr().String()
+	result, err := tc.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Healthy {
+		t.Fatalf("Expected result.Healthy=true, got %s: %s", result.Status(), result.Notice)
+	}
+	select {
+	case msg := <-messages:
+		for _, want := range []string{"From: checkup@example.com\r\n", "To: sink@example.com\r\n", "Subject: Delivery test\r\n"} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("Expected message to contain %q, got %q", want, msg)
+			}
+		}
+	default:
+		t.Error("Expected a message to be delivered, got none")
+	}
+
+	if _, err := (Checker{To: "sink@example.com"}).Check(); err == nil {
+		t.Error("Expected an error for a message without a sender, didn't get one")
+	}
+}
+
+// serve starts a fake SMTP server that accepts the password
+// "secret" and mail to sink@example.com, delivering messages
+// to messages. If config is not nil, it supports STARTTLS.
+func serve(t *testing.T, config *tls.Config, messages chan<- string) net.Listener {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn, config, messages)
+		}
+	}()
+	return ln
+}
+
+// handle runs an SMTP session over conn.
+func handle(conn net.Conn, config *tls.Config, messages chan<- string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) {
+		conn.Write([]byte(s + "\r\n"))
+	}
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.Fields(line + " ")[0])
+		switch verb {
+		case "EHLO":
+			if config != nil {
+				reply("250-localhost\r\n250-STARTTLS\r\n250 AUTH PLAIN")
+			} else {
+				reply("250-localhost\r\n250 AUTH PLAIN")
+			}
+		case "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, config)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, r = tlsConn, bufio.NewReader(tlsConn)
+		case "AUTH":
+			creds, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+			if string(creds) == "\x00checkup\x00secret" {
+				reply("235 authenticated")
+			} else {
+				reply("535 authentication failed")
+			}
+		case "MAIL":
+			reply("250 ok")
+		case "RCPT":
+			if strings.Contains(line, "<sink@example.com>") {
+				reply("250 ok")
+			} else {
+				reply("550 no such user")
+			}
+		case "DATA":
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				msg.WriteString(line)
+			}
+			messages <- msg.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown command")
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package ssh
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "ssh"
+
+// Checker implements a Checker for SSH servers.
+//
+// Each attempt connects to the server, checks its version
+// banner and host key, and, if Username is set, authenticates
+// with Password or KeyFile. If Command is also set, it is run
+// and its exit status and output are asserted, like the exec
+// checker does. Without a Username, the check stops once the
+// server asks for authentication.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the address of the server, as host:port.
+	URL string `json:"endpoint_url"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Banner is a string that the version banner of the
+	// server, such as "SSH-2.0-OpenSSH_9.6", must
+	// contain in order to be considered up.
+	Banner string `json:"banner,omitempty"`
+
+	// HostKeyFingerprints pins the host key of the
+	// server: if set, its fingerprint must be one of
+	// these, in the SHA256:... form printed by
+	// ssh-keygen -l, or the legacy MD5 form. If empty,
+	// any host key is accepted.
+	HostKeyFingerprints []string `json:"host_key_fingerprints,omitempty"`
+
+	// HostKeyAlgorithms are the host key algorithms to
+	// accept, in order of preference, such as
+	// "ssh-ed25519". Set it to get the type of host key
+	// that HostKeyFingerprints pins.
+	HostKeyAlgorithms []string `json:"host_key_algorithms,omitempty"`
+
+	// Username is the user to authenticate as. If empty,
+	// no authentication is attempted.
+	Username string `json:"username,omitempty"`
+
+	// Password is the password to authenticate with.
+	Password string `json:"password,omitempty"`
+
+	// KeyFile is the path to a PEM-encoded private key
+	// to authenticate with, which is decrypted with
+	// KeyPassphrase if set.
+	KeyFile       string `json:"key_file,omitempty"`
+	KeyPassphrase string `json:"key_passphrase,omitempty"`
+
+	// Command is a command to run once authenticated.
+	Command string `json:"command,omitempty"`
+
+	// ExitStatus is the exit status Command must exit
+	// with in order to be considered up. Default is 0.
+	ExitStatus int `json:"exit_status,omitempty"`
+
+	// MustContain is a string that the output of
+	// Command must contain in order to be considered
+	// up.
+	MustContain string `json:"must_contain,omitempty"`
+
+	// MustNotContain is a string that the output of
+	// Command must NOT contain in order to be
+	// considered up.
+	MustNotContain string `json:"must_not_contain,omitempty"`
+
+	// Timeout is the maximum time each attempt may
+	// take, including running Command. Default is 10
+	// seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. If non-zero and a
+	// request takes longer than ThresholdRTT, the
+	// endpoint will be considered unhealthy. Note that
+	// this duration includes any in-between network
+	// latency.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many requests the client will
+	// make to the endpoint in a single check.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.Command != "" && c.Username == "" {
+		return types.Result{}, errors.New("ssh: command requires a username")
+	}
+	auth, err := c.auth()
+	if err != nil {
+		return types.Result{}, err
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+	result.Tags = c.Tags
+	result.Times = c.doChecks(auth)
+
+	return c.conclude(result), nil
+}
+
+// auth returns the authentication methods described by c.
+func (c Checker) auth() ([]ssh.AuthMethod, error) {
+	var auth []ssh.AuthMethod
+	if c.KeyFile != "" {
+		pemBytes, err := ioutil.ReadFile(c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		var signer ssh.Signer
+		if c.KeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(c.KeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(pemBytes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ssh: parsing %s: %w", c.KeyFile, err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if c.Password != "" {
+		auth = append(auth, ssh.Password(c.Password))
+	}
+	return auth, nil
+}
+
+// doChecks executes and returns each attempt.
+func (c Checker) doChecks(auth []ssh.AuthMethod) types.Attempts {
+	checks := make(types.Attempts, c.Attempts)
+	for i := 0; i < c.Attempts; i++ {
+		start := time.Now()
+		err := c.attempt(auth)
+		checks[i].RTT = time.Since(start)
+		if err != nil {
+			checks[i].Error = err.Error()
+		}
+	}
+	return checks
+}
+
+// attempt connects to the server and runs c.Command.
+func (c Checker) attempt(auth []ssh.AuthMethod) error {
+	conn, err := net.DialTimeout("tcp", c.URL, c.Timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return err
+	}
+
+	rec := &recordingConn{Conn: conn}
+	var verified bool
+	config := &ssh.ClientConfig{
+		User:              c.Username,
+		Auth:              auth,
+		HostKeyAlgorithms: c.HostKeyAlgorithms,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if err := c.checkBanner(rec.banner()); err != nil {
+				return err
+			}
+			if err := c.checkHostKey(key); err != nil {
+				return err
+			}
+			verified = true
+			return nil
+		},
+		Timeout: c.Timeout,
+	}
+	if c.Username == "" {
+		config.User = "checkup"
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(rec, c.URL, config)
+	if err != nil {
+		if c.Username == "" && verified && strings.Contains(err.Error(), "unable to authenticate") {
+			// the server is up and asked for credentials
+			return nil
+		}
+		if !verified && rec.banner() != "" {
+			if bannerErr := c.checkBanner(rec.banner()); bannerErr != nil {
+				return bannerErr
+			}
+		}
+		return err
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+	defer client.Close()
+
+	if c.Command == "" {
+		return nil
+	}
+	return c.run(client)
+}
+
+// run runs c.Command with client and checks its outcome.
+func (c Checker) run(client *ssh.Client) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	var out lockedBuffer
+	session.Stdout = &out
+	session.Stderr = &out
+	err = session.Run(c.Command)
+	status := 0
+	if exitErr, ok := err.(*ssh.ExitError); ok {
+		status = exitErr.ExitStatus()
+	} else if err != nil {
+		return err
+	}
+	if status != c.ExitStatus {
+		return fmt.Errorf("command exited with status %d, expected %d: %s", status, c.ExitStatus, strings.TrimSpace(out.String()))
+	}
+	return c.checkDown(out.String())
+}
+
+// checkBanner checks the version banner of the server.
+func (c Checker) checkBanner(banner string) error {
+	if c.Banner != "" && !strings.Contains(banner, c.Banner) {
+		return fmt.Errorf("banner '%s' does not contain '%s'", banner, c.Banner)
+	}
+	return nil
+}
+
+// checkHostKey checks key against c.HostKeyFingerprints.
+func (c Checker) checkHostKey(key ssh.PublicKey) error {
+	if len(c.HostKeyFingerprints) == 0 {
+		return nil
+	}
+	sha256, md5 := ssh.FingerprintSHA256(key), ssh.FingerprintLegacyMD5(key)
+	for _, fingerprint := range c.HostKeyFingerprints {
+		fingerprint = strings.TrimPrefix(fingerprint, "MD5:")
+		if fingerprint == sha256 || fingerprint == md5 {
+			return nil
+		}
+	}
+	return fmt.Errorf("host key %s %s is not pinned", key.Type(), sha256)
+}
+
+// checkDown checks whether the endpoint is down based on the
+// output of c.Command. It returns a non-nil error if down.
+func (c Checker) checkDown(out string) error {
+	if c.MustContain != "" && !strings.Contains(out, c.MustContain) {
+		return fmt.Errorf("output does not contain '%s'", c.MustContain)
+	}
+	if c.MustNotContain != "" && strings.Contains(out, c.MustNotContain) {
+		return fmt.Errorf("output contains '%s'", c.MustNotContain)
+	}
+	return nil
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It detects degraded (high-latency) responses and makes
+// the conclusion about the result's status.
+func (c Checker) conclude(result types.Result) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Notice = result.Times[i].Error
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check round trip time (degraded)
+	if c.ThresholdRTT > 0 {
+		stats := result.ComputeStats()
+		if stats.Median > c.ThresholdRTT {
+			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// lockedBuffer is a bytes.Buffer that can be written to
+// concurrently, as stdout and stderr are.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write appends p to the buffer.
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// String returns the contents of the buffer.
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// recordingConn is a net.Conn that records the beginning of
+// what it reads, in order to get the version banner of the
+// server, which the ssh package doesn't expose until it's
+// authenticated.
+type recordingConn struct {
+	net.Conn
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// maxRecorded is how many bytes a recordingConn records.
+const maxRecorded = 4096
+
+// Read reads from the connection, recording what it reads.
+func (r *recordingConn) Read(p []byte) (int, error) {
+	n, err := r.Conn.Read(p)
+	r.mu.Lock()
+	if room := maxRecorded - len(r.buf); room > 0 {
+		if room > n {
+			room = n
+		}
+		r.buf = append(r.buf, p[:room]...)
+	}
+	r.mu.Unlock()
+	return n, err
+}
+
+// banner returns the version banner of the server, which is
+// the first line that starts with "SSH-".
+func (r *recordingConn) banner() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, line := range strings.Split(string(r.buf), "\n") {
+		if strings.HasPrefix(line, "SSH-") {
+			return strings.TrimRight(line, "\r")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,179 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestChecker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup-ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	hostKey, _ := newKey(t)
+	clientKey, clientPrivateKey := newKey(t)
+	keyFile := filepath.Join(dir, "id_ed25519")
+	block, err := ssh.MarshalPrivateKey(clientPrivateKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ln := serve(t, hostKey, clientKey)
+	defer ln.Close()
+
+	pinned := ssh.FingerprintSHA256(hostKey.PublicKey())
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+	}{
+		{Checker{}, "healthy", ""},
+		{Checker{Banner: "TestSSH_1.0", HostKeyFingerprints: []string{"SHA256:other", pinned}}, "healthy", ""},
+		{Checker{HostKeyFingerprints: []string{ssh.FingerprintLegacyMD5(hostKey.PublicKey())}}, "healthy", ""},
+		{Checker{Banner: "OpenSSH"}, "down", "banner 'SSH-2.0-TestSSH_1.0' does not contain 'OpenSSH'"},
+		{Checker{HostKeyFingerprints: []string{"SHA256:other"}}, "down", "ssh: handshake failed: host key ssh-ed25519 " + pinned + " is not pinned"},
+		{Checker{Username: "checkup", Password: "secret"}, "healthy", ""},
+		{Checker{Username: "checkup", Password: "wrong"}, "down", "ssh: handshake failed: ssh: unable to authenticate"},
+		{Checker{Username: "checkup", KeyFile: keyFile, Command: "echo hello", MustContain: "hello"}, "healthy", ""},
+		{Checker{Username: "checkup", KeyFile: keyFile, Command: "echo hello", MustNotContain: "hello"}, "down", "output contains 'hello'"},
+		{Checker{Username: "checkup", KeyFile: keyFile, Command: "echo hello", MustContain: "bye"}, "down", "output does not contain 'bye'"},
+		{Checker{Username: "checkup", KeyFile: keyFile, Command: "false"}, "down", "command exited with status 1, expected 0: failed"},
+		{Checker{Username: "checkup", KeyFile: keyFile, Command: "false", ExitStatus: 1}, "healthy", ""},
+		{Checker{ThresholdRTT: time.Nanosecond}, "degraded", "median round trip time exceeded threshold"},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = ln.Addr().String()
+		tc.Attempts = 2
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+	}
+
+	for _, tc := range []Checker{
+		{URL: ln.Addr().String(), Command: "echo hello"},
+		{URL: ln.Addr().String(), Username: "checkup", KeyFile: filepath.Join(dir, "missing")},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
+
+// newKey returns a new ed25519 key, and its signer.
+func newKey(t *testing.T) (ssh.Signer, ed25519.PrivateKey) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, key
+}
+
+// serve starts an SSH server with hostKey, which accepts the
+// password "secret" and clientKey, and runs the commands
+// "echo hello" and "false".
+func serve(t *testing.T, hostKey ssh.Signer, clientKey ssh.Signer) net.Listener {
+	config := &ssh.ServerConfig{
+		ServerVersion: "SSH-2.0-TestSSH_1.0",
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("denied")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(clientKey.PublicKey().Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("denied")
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn, config)
+		}
+	}()
+	return ln
+}
+
+// handle runs an SSH session over conn.
+func handle(conn net.Conn, config *ssh.ServerConfig) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			newChan.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		ch, requests, err := newChan.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer ch.Close()
+			for req := range requests {
+				if req.Type != "exec" {
+					req.Reply(false, nil)
+					continue
+				}
+				command := string(req.Payload[4:])
+				req.Reply(true, nil)
+				status := uint32(0)
+				switch command {
+				case "echo hello":
+					ch.Write([]byte("hello\n"))
+				default:
+					ch.Stderr().Write([]byte("failed\n"))
+					status = 1
+				}
+				payload := make([]byte, 4)
+				binary.BigEndian.PutUint32(payload, status)
+				ch.SendRequest("exit-status", false, payload)
+				return
+			}
+		}()
+	}
+}
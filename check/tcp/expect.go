@@ -0,0 +1,150 @@
+package tcp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxResponseSize is the most that is read of a response
+// while waiting for it to satisfy the expectations.
+const maxResponseSize = 64 * 1024
+
+// exchange is a payload to send on a connection and the
+// expectations that the first response must satisfy.
+type exchange struct {
+	send        []byte
+	expect      []byte
+	expectHex   []byte
+	expectRegex *regexp.Regexp
+	readTimeout time.Duration
+
+	// datagram is whether the response is a single
+	// datagram rather than a stream.
+	datagram bool
+}
+
+// exchange returns the exchange configured by c, or nil if
+// there is nothing to send or expect.
+func (c Checker) exchange() (*exchange, error) {
+	if !c.UDP && c.Send == "" && c.Expect == "" && c.ExpectRegex == "" && c.ExpectHex == "" {
+		return nil, nil
+	}
+
+	ex := &exchange{
+		expect:      []byte(c.Expect),
+		readTimeout: c.ReadTimeout,
+		datagram:    c.UDP,
+	}
+	if ex.readTimeout == 0 {
+		ex.readTimeout = 5 * time.Second
+	}
+	send, err := unescape(c.Send)
+	if err != nil {
+		return nil, fmt.Errorf("invalid send payload: %w", err)
+	}
+	ex.send = []byte(send)
+	if c.ExpectHex != "" {
+		ex.expectHex, err = hex.DecodeString(strings.NewReplacer(" ", "", ":", "").Replace(c.ExpectHex))
+		if err != nil {
+			return nil, fmt.Errorf("invalid expect_hex: %w", err)
+		}
+	}
+	if c.ExpectRegex != "" {
+		ex.expectRegex, err = regexp.Compile(c.ExpectRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expect_regex: %w", err)
+		}
+	}
+	return ex, nil
+}
+
+// run sends the payload on conn and reads the response until
+// it satisfies the expectations. It returns an error if they
+// aren't satisfied before the read timeout.
+func (ex *exchange) run(conn net.Conn) error {
+	if err := conn.SetDeadline(time.Now().Add(ex.readTimeout)); err != nil {
+		return err
+	}
+	if len(ex.send) > 0 || ex.datagram {
+		if _, err := conn.Write(ex.send); err != nil {
+			return err
+		}
+	}
+	if !ex.expecting() && !ex.datagram {
+		return nil
+	}
+
+	var resp []byte
+	buf := make([]byte, maxResponseSize)
+	for len(resp) < maxResponseSize {
+		n, err := conn.Read(buf[:maxResponseSize-len(resp)])
+		resp = append(resp, buf[:n]...)
+		if n > 0 && ex.match(resp) == nil {
+			return nil
+		}
+		if err != nil {
+			if len(resp) == 0 {
+				return fmt.Errorf("no response: %w", err)
+			}
+			break
+		}
+		if ex.datagram {
+			break
+		}
+	}
+	return ex.match(resp)
+}
+
+// expecting returns whether ex has any expectations of the
+// response.
+func (ex *exchange) expecting() bool {
+	return len(ex.expect) > 0 || len(ex.expectHex) > 0 || ex.expectRegex != nil
+}
+
+// match checks resp against the expectations of ex. It
+// returns a non-nil error if they aren't satisfied.
+func (ex *exchange) match(resp []byte) error {
+	if len(ex.expect) > 0 && !bytes.Contains(resp, ex.expect) {
+		return fmt.Errorf("response does not contain '%s': %q", ex.expect, truncate(resp))
+	}
+	if len(ex.expectHex) > 0 && !bytes.Contains(resp, ex.expectHex) {
+		return fmt.Errorf("response does not contain bytes %x: %q", ex.expectHex, truncate(resp))
+	}
+	if ex.expectRegex != nil && !ex.expectRegex.Match(resp) {
+		return fmt.Errorf("response does not match '%s': %q", ex.expectRegex, truncate(resp))
+	}
+	return nil
+}
+
+// truncate shortens resp for use in error messages.
+func truncate(resp []byte) []byte {
+	if len(resp) > 128 {
+		return resp[:128]
+	}
+	return resp
+}
+
+// unescape interprets the backslash escape sequences of Go
+// string literals in s.
+func unescape(s string) (string, error) {
+	var sb strings.Builder
+	for len(s) > 0 {
+		r, multibyte, tail, err := strconv.UnquoteChar(s, 0)
+		if err != nil {
+			return "", err
+		}
+		if r < 0x100 && !multibyte {
+			sb.WriteByte(byte(r))
+		} else {
+			sb.WriteRune(r)
+		}
+		s = tail
+	}
+	return sb.String(), nil
+}
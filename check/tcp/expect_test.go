@@ -0,0 +1,130 @@
+package tcp
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckerSendExpect(t *testing.T) {
+	srv, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	// greet with a banner, then answer PING with PONG
+	// and stay silent otherwise
+	go func() {
+		for {
+			conn, err := srv.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				_ = conn.SetDeadline(time.Now().Add(time.Second))
+				conn.Write([]byte("SSH-2.0-OpenSSH_8.2\r\n"))
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil {
+					return
+				}
+				switch line {
+				case "PING\r\n":
+					conn.Write([]byte("+PONG\r\n"))
+				case "\x00\x01\r\n":
+					conn.Write([]byte{0xca, 0xfe, '\r', '\n'})
+				}
+				_, _ = conn.Read(make([]byte, 1))
+			}(conn)
+		}
+	}()
+
+	for i, test := range []struct {
+		checker Checker
+		healthy bool
+	}{
+		{Checker{Expect: "SSH-2.0-"}, true},
+		{Checker{Expect: "220 "}, false},
+		{Checker{ExpectRegex: `^SSH-2\.0-OpenSSH_[0-9.]+`}, true},
+		{Checker{ExpectRegex: `^SSH-1\.`}, false},
+		{Checker{Send: `PING\r\n`, Expect: "+PONG"}, true},
+		{Checker{Send: "PING\r\n", Expect: "+PONG"}, true},
+		{Checker{Send: `QUIT\r\n`, Expect: "+PONG"}, false},
+		{Checker{Send: `\x00\x01\r\n`, ExpectHex: "ca:fe"}, true},
+		{Checker{Send: `\x00\x01\r\n`, ExpectHex: "de ad"}, false},
+		{Checker{Send: `PING\r\n`}, true},
+	} {
+		tc := test.checker
+		tc.URL = srv.Addr().String()
+		tc.ReadTimeout = 200 * time.Millisecond
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if result.Healthy != test.healthy {
+			t.Errorf("Test %d: Expected result.Healthy=%v, got %v: %v", i, test.healthy, result.Healthy, result.Times)
+		}
+	}
+
+	for _, tc := range []Checker{
+		{URL: srv.Addr().String(), Send: `\q`},
+		{URL: srv.Addr().String(), ExpectHex: "xyz"},
+		{URL: srv.Addr().String(), ExpectRegex: "("},
+		{URL: srv.Addr().String(), UDP: true, TLSEnabled: true},
+	} {
+		if _, err := tc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", tc)
+		}
+	}
+}
+
+func TestCheckerUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// reply to "stats" datagrams only
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(string(buf[:n]), "stats") {
+				conn.WriteTo([]byte("STAT pid 1\r\nEND\r\n"), addr)
+			}
+		}
+	}()
+
+	for i, test := range []struct {
+		checker Checker
+		healthy bool
+	}{
+		{Checker{Send: `stats\r\n`}, true},
+		{Checker{Send: `stats\r\n`, Expect: "STAT pid"}, true},
+		{Checker{Send: `stats\r\n`, ExpectRegex: `(?m)^END\r$`}, true},
+		{Checker{Send: `stats\r\n`, Expect: "STAT uptime"}, false},
+		{Checker{Send: `version\r\n`}, false},
+	} {
+		tc := test.checker
+		tc.URL = conn.LocalAddr().String()
+		tc.UDP = true
+		tc.ReadTimeout = 200 * time.Millisecond
+		tc.Attempts = 2
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if result.Healthy != test.healthy {
+			t.Errorf("Test %d: Expected result.Healthy=%v, got %v: %v", i, test.healthy, result.Healthy, result.Times)
+		}
+	}
+}
@@ -18,6 +18,14 @@ var (
 	errParsingRootCert = errors.New("error parsing root certificate")
 )
 
+// tlsVersions maps the names of TLS versions to their values.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
 // Type should match the package name
 const Type = "tcp"
 
@@ -29,6 +37,14 @@ type Checker struct {
 	// URL is the URL of the endpoint.
 	URL string `json:"endpoint_url"`
 
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// UDP controls whether to check a UDP endpoint
+	// instead. If set, a datagram with the Send payload
+	// is sent and a reply is expected.
+	UDP bool `json:"udp,omitempty"`
+
 	// TLSEnabled controls whether to enable TLS or not.
 	// If set, TLS is enabled.
 	TLSEnabled bool `json:"tls,omitempty"`
@@ -41,10 +57,50 @@ type Checker struct {
 	// to validate the server TLS certificate.
 	TLSCAFile string `json:"tls_ca_file,omitempty"`
 
+	// TLSCertFile and TLSKeyFile are the PEM-encoded
+	// client certificate and key to present to servers
+	// that require mutual TLS. Note that with TLS 1.3 a
+	// rejected certificate is only reported once data is
+	// read, so set Expect or similar to detect it.
+	TLSCertFile string `json:"tls_cert_file,omitempty"`
+	TLSKeyFile  string `json:"tls_key_file,omitempty"`
+
+	// TLSServerName is the name used for SNI and to
+	// verify the server TLS certificate. Default is the
+	// host from URL.
+	TLSServerName string `json:"tls_server_name,omitempty"`
+
+	// TLSMinVersion is the minimum TLS version to
+	// negotiate, such as "1.2".
+	TLSMinVersion string `json:"tls_min_version,omitempty"`
+
 	// Timeout is the maximum time to wait for a
 	// TCP connection to be established.
 	Timeout time.Duration `json:"timeout,omitempty"`
 
+	// Send is a payload to send once connected, such
+	// as "PING\r\n". Backslash escape sequences like
+	// \r, \n, \t, \x00 and \u00e9 are interpreted.
+	Send string `json:"send,omitempty"`
+
+	// Expect is a string that the first response
+	// must contain in order to be considered up.
+	Expect string `json:"expect,omitempty"`
+
+	// ExpectRegex is a regular expression that the
+	// first response must match in order to be
+	// considered up.
+	ExpectRegex string `json:"expect_regex,omitempty"`
+
+	// ExpectHex is a hex-encoded sequence of bytes that
+	// the first response must contain in order to be
+	// considered up. Spaces and colons are ignored.
+	ExpectHex string `json:"expect_hex,omitempty"`
+
+	// ReadTimeout is the maximum time to wait for the
+	// first response. Default is 5 seconds.
+	ReadTimeout time.Duration `json:"read_timeout,omitempty"`
+
 	// ThresholdRTT is the maximum round trip time to
 	// allow for a healthy endpoint. If non-zero and a
 	// request takes longer than ThresholdRTT, the
@@ -64,11 +120,25 @@ func (c Checker) Check() (types.Result, error) {
 	if c.Attempts < 1 {
 		c.Attempts = 1
 	}
+	if c.UDP && c.TLSEnabled {
+		return types.Result{}, errors.New("tls is not supported with udp")
+	}
+	ex, err := c.exchange()
+	if err != nil {
+		return types.Result{}, err
+	}
+	var tlsConfig *tls.Config
+	if c.TLSEnabled {
+		if tlsConfig, err = c.tlsConfig(); err != nil {
+			return types.Result{}, err
+		}
+	}
 
 	result := types.NewResult()
 	result.Title = c.Name
 	result.Endpoint = c.URL
-	result.Times = c.doChecks()
+	result.Tags = c.Tags
+	result.Times = c.doChecks(ex, tlsConfig)
 
 	return c.conclude(result), nil
 }
@@ -85,8 +155,54 @@ func (Checker) Type() string {
 	return Type
 }
 
-// doChecks executes and returns each attempt.
-func (c Checker) doChecks() types.Attempts {
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// tlsConfig returns the TLS config described by c.
+func (c Checker) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.TLSSkipVerify,
+		ServerName:         c.TLSServerName,
+	}
+	if c.TLSCAFile != "" {
+		rootPEM, err := ioutil.ReadFile(c.TLSCAFile)
+		if err != nil || rootPEM == nil {
+			return nil, errReadingRootCert
+		}
+		pool := x509.NewCertPool()
+		ok := pool.AppendCertsFromPEM(rootPEM)
+		if !ok {
+			return nil, errParsingRootCert
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.TLSCertFile != "" || c.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if c.TLSMinVersion != "" {
+		version, ok := tlsVersions[c.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown TLS version: %s", c.TLSMinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+	return tlsConfig, nil
+}
+
+// doChecks executes and returns each attempt. If tlsConfig
+// is not nil, a TLS connection is made with it.
+func (c Checker) doChecks(ex *exchange, tlsConfig *tls.Config) types.Attempts {
 	var err error
 	var conn net.Conn
 
@@ -95,32 +211,20 @@ func (c Checker) doChecks() types.Attempts {
 		timeout = time.Second
 	}
 
+	network := "tcp"
+	if c.UDP {
+		network = "udp"
+	}
 	dialer := func() (net.Conn, error) {
-		return net.DialTimeout("tcp", c.URL, timeout)
+		return net.DialTimeout(network, c.URL, timeout)
 	}
-	if c.TLSEnabled {
+	if tlsConfig != nil {
 		dialer = func() (net.Conn, error) {
 			// Dialer with timeout
 			dialer := &net.Dialer{
 				Timeout: timeout,
 			}
-
-			// TLS config based on configuration
-			var tlsConfig tls.Config
-			tlsConfig.InsecureSkipVerify = c.TLSSkipVerify
-			if c.TLSCAFile != "" {
-				rootPEM, err := ioutil.ReadFile(c.TLSCAFile)
-				if err != nil || rootPEM == nil {
-					return nil, errReadingRootCert
-				}
-				pool := x509.NewCertPool()
-				ok := pool.AppendCertsFromPEM(rootPEM)
-				if !ok {
-					return nil, errParsingRootCert
-				}
-				tlsConfig.RootCAs = pool
-			}
-			return tls.DialWithDialer(dialer, "tcp", c.URL, &tlsConfig)
+			return tls.DialWithDialer(dialer, "tcp", c.URL, tlsConfig)
 		}
 	}
 
@@ -129,6 +233,9 @@ func (c Checker) doChecks() types.Attempts {
 		start := time.Now()
 
 		if conn, err = dialer(); err == nil {
+			if ex != nil {
+				err = ex.run(conn)
+			}
 			conn.Close()
 		}
 
@@ -2,6 +2,8 @@ package tcp
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
 	"net"
 	"testing"
 	"time"
@@ -433,3 +435,97 @@ func TestCheckerWithTLSVerifyError(t *testing.T) {
 		t.Errorf("Expected timestamp to be recent, got %s", ts)
 	}
 }
+
+func TestCheckerWithMutualTLS(t *testing.T) {
+	certPair, err := tls.LoadX509KeyPair("testdata/leaf.pem", "testdata/leaf.key")
+	if err != nil {
+		t.Fatal("Failed to load certificate.", err)
+	}
+	rootPEM, err := ioutil.ReadFile("testdata/root.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(rootPEM)
+	config := tls.Config{
+		Certificates: []tls.Certificate{certPair},
+		ClientAuth:   tls.RequireAnyClientCert,
+		MinVersion:   tls.VersionTLS12,
+		// the test root CA is restricted to server auth, so
+		// verify the client certificate with any usage
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			_, err = cert.Verify(x509.VerifyOptions{
+				Roots:     clientCAs,
+				KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+			})
+			return err
+		},
+	}
+	srv, err := tls.Listen("tcp", "127.0.0.1:0", &config)
+	if err != nil {
+		t.Fatalf("There was an error while starting TLS: %v", err)
+	}
+	defer srv.Close()
+
+	// Accept connections and greet verified clients; with
+	// TLS 1.3 a rejected client certificate only surfaces
+	// on the client's first read
+	go func() {
+		for {
+			conn, err := srv.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.SetDeadline(time.Now().Add(100 * time.Millisecond))
+			if conn.(*tls.Conn).Handshake() == nil {
+				conn.Write([]byte("OK\n"))
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	endpt := srv.Addr().String()
+	for i, test := range []struct {
+		checker Checker
+		healthy bool
+	}{
+		{Checker{TLSCertFile: "testdata/client.pem", TLSKeyFile: "testdata/client.key"}, true},
+		{Checker{TLSCertFile: "testdata/client.pem", TLSKeyFile: "testdata/client.key", TLSServerName: "localhost"}, true},
+		{Checker{TLSCertFile: "testdata/client.pem", TLSKeyFile: "testdata/client.key", TLSServerName: "example.com"}, false},
+		{Checker{TLSCertFile: "testdata/client.pem", TLSKeyFile: "testdata/client.key", TLSMinVersion: "1.3"}, true},
+		{Checker{}, false},
+	} {
+		hc := test.checker
+		hc.Name = "TestWithMutualTLS"
+		hc.URL = endpt
+		hc.TLSEnabled = true
+		hc.TLSCAFile = "testdata/root.pem"
+		hc.Expect = "OK"
+		hc.ReadTimeout = time.Second
+		hc.Attempts = 2
+
+		result, err := hc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := result.Healthy, test.healthy; got != want {
+			t.Errorf("Test %d: Expected result.Healthy=%v, got %v: %v", i, want, got, result.Times)
+		}
+	}
+
+	for _, hc := range []Checker{
+		{URL: endpt, TLSEnabled: true, TLSCertFile: "testdata/client.pem"},
+		{URL: endpt, TLSEnabled: true, TLSCertFile: "testdata/client.pem", TLSKeyFile: "testdata/leaf.key"},
+		{URL: endpt, TLSEnabled: true, TLSCAFile: "testdata/missing.pem"},
+		{URL: endpt, TLSEnabled: true, TLSMinVersion: "0.9"},
+	} {
+		if _, err := hc.Check(); err == nil {
+			t.Errorf("Expected an error for %+v, didn't get one", hc)
+		}
+	}
+}
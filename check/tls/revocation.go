@@ -0,0 +1,233 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// Revocation checking modes for Checker.Revocation.
+const (
+	// RevocationStaple checks only the OCSP response
+	// stapled to the handshake.
+	RevocationStaple = "staple"
+
+	// RevocationOCSP checks the stapled OCSP response,
+	// or queries the OCSP responder if none is stapled.
+	RevocationOCSP = "ocsp"
+
+	// RevocationCRL checks the certificate revocation
+	// list from the CRL distribution point.
+	RevocationCRL = "crl"
+)
+
+// Actions to take on revocation check outcomes.
+const (
+	ActionDown     = "down"
+	ActionDegraded = "degraded"
+	ActionIgnore   = "ignore"
+)
+
+// Limits on the size of the responses of OCSP responders and
+// CRL distribution points. CRLs of large CAs can be big.
+const (
+	maxOCSPResponseSize = 1 << 20
+	maxCRLSize          = 32 << 20
+)
+
+// revocationStatus is the outcome of a revocation check.
+type revocationStatus int
+
+const (
+	revocationGood revocationStatus = iota
+	revocationRevoked
+	revocationUnknown
+	revocationMissingStaple
+)
+
+// checkRevocation determines the revocation status of the
+// leaf certificate of the connection described by state. The
+// returned message describes any status other than good.
+func (c Checker) checkRevocation(state tls.ConnectionState) (revocationStatus, string) {
+	certs := state.PeerCertificates
+	if len(certs) == 0 {
+		return revocationUnknown, "no certificates presented"
+	}
+	leaf := certs[0]
+	issuer := issuerOf(state)
+
+	if c.Revocation == RevocationCRL {
+		return c.checkCRL(leaf, issuer)
+	}
+
+	der := state.OCSPResponse
+	if der == nil {
+		if c.Revocation == RevocationStaple {
+			return revocationMissingStaple, "no OCSP response stapled"
+		}
+		var err error
+		der, err = c.fetchOCSP(leaf, issuer)
+		if err != nil {
+			return revocationUnknown, fmt.Sprintf("could not query OCSP responder: %v", err)
+		}
+	}
+
+	if issuer == nil {
+		// the signature of the response can't be verified
+		return revocationUnknown, "issuer certificate not available to verify OCSP response"
+	}
+	resp, err := ocsp.ParseResponseForCert(der, leaf, issuer)
+	if err != nil {
+		return revocationUnknown, fmt.Sprintf("invalid OCSP response: %v", err)
+	}
+	if !resp.NextUpdate.IsZero() && resp.NextUpdate.Before(time.Now()) {
+		return revocationUnknown, fmt.Sprintf("OCSP response expired %s ago", time.Since(resp.NextUpdate))
+	}
+	switch resp.Status {
+	case ocsp.Good:
+		return revocationGood, ""
+	case ocsp.Revoked:
+		return revocationRevoked, fmt.Sprintf("certificate revoked at %s (OCSP)", resp.RevokedAt.UTC())
+	}
+	return revocationUnknown, "OCSP status unknown"
+}
+
+// fetchOCSP queries the OCSP responder about leaf and returns
+// the raw response.
+func (c Checker) fetchOCSP(leaf, issuer *x509.Certificate) ([]byte, error) {
+	url := c.OCSPURL
+	if url == "" {
+		if len(leaf.OCSPServer) == 0 {
+			return nil, fmt.Errorf("certificate has no OCSP responder")
+		}
+		url = leaf.OCSPServer[0]
+	}
+	if issuer == nil {
+		return nil, fmt.Errorf("issuer certificate not available")
+	}
+	req, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient().Post(url, "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response status %s", resp.Status)
+	}
+	return readLimited(resp.Body, maxOCSPResponseSize)
+}
+
+// checkCRL checks whether leaf is listed in the CRL of its
+// distribution point.
+func (c Checker) checkCRL(leaf, issuer *x509.Certificate) (revocationStatus, string) {
+	url := c.CRLURL
+	if url == "" {
+		if len(leaf.CRLDistributionPoints) == 0 {
+			return revocationUnknown, "certificate has no CRL distribution point"
+		}
+		url = leaf.CRLDistributionPoints[0]
+	}
+	if issuer == nil {
+		// the signature of the CRL can't be verified
+		return revocationUnknown, "issuer certificate not available to verify CRL"
+	}
+	resp, err := c.httpClient().Get(url)
+	if err != nil {
+		return revocationUnknown, fmt.Sprintf("could not fetch CRL: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return revocationUnknown, fmt.Sprintf("could not fetch CRL: response status %s", resp.Status)
+	}
+	der, err := readLimited(resp.Body, maxCRLSize)
+	if err != nil {
+		return revocationUnknown, fmt.Sprintf("could not fetch CRL: %v", err)
+	}
+
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return revocationUnknown, fmt.Sprintf("invalid CRL: %v", err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return revocationUnknown, fmt.Sprintf("invalid CRL signature: %v", err)
+	}
+	if !crl.NextUpdate.IsZero() && crl.NextUpdate.Before(time.Now()) {
+		return revocationUnknown, fmt.Sprintf("CRL expired %s ago", time.Since(crl.NextUpdate))
+	}
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+			return revocationRevoked, fmt.Sprintf("certificate revoked at %s (CRL)", entry.RevocationTime.UTC())
+		}
+	}
+	return revocationGood, ""
+}
+
+// action returns the configured action for status.
+func (c Checker) action(status revocationStatus) string {
+	action := ActionIgnore
+	switch status {
+	case revocationRevoked:
+		action = ActionDown
+		if c.OnRevoked != "" {
+			action = c.OnRevoked
+		}
+	case revocationUnknown:
+		action = ActionDegraded
+		if c.OnUnknown != "" {
+			action = c.OnUnknown
+		}
+	case revocationMissingStaple:
+		action = ActionDegraded
+		if c.OnMissingStaple != "" {
+			action = c.OnMissingStaple
+		}
+	}
+	return action
+}
+
+// readLimited reads r to the end, failing if it is longer
+// than max bytes.
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	b, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > max {
+		return nil, fmt.Errorf("response larger than %d bytes", max)
+	}
+	return b, nil
+}
+
+// httpClient returns the client used to query OCSP responders
+// and CRL distribution points.
+func (c Checker) httpClient() *http.Client {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// issuerOf returns the issuer of the leaf certificate of the
+// connection described by state, or nil if it is unknown.
+func issuerOf(state tls.ConnectionState) *x509.Certificate {
+	for _, chain := range state.VerifiedChains {
+		if len(chain) > 1 {
+			return chain[1]
+		}
+	}
+	if len(state.PeerCertificates) > 1 {
+		return state.PeerCertificates[1]
+	}
+	return nil
+}
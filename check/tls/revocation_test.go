@@ -0,0 +1,198 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestCheckerRevocation(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Checkup Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour * 365),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour * 30),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		OCSPServer:            []string{"http://ocsp.invalid"},
+		CRLDistributionPoints: []string{"http://crl.invalid"},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ocspResponse := func(status int) []byte {
+		resp, err := ocsp.CreateResponse(ca, ca, ocsp.Response{
+			Status:       status,
+			SerialNumber: leaf.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Hour),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now().Add(-time.Minute),
+		}, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	crl := func(revoked ...*big.Int) []byte {
+		var entries []x509.RevocationListEntry
+		for _, serial := range revoked {
+			entries = append(entries, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: time.Now().Add(-time.Minute)})
+		}
+		der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:                    big.NewInt(1),
+			ThisUpdate:                time.Now().Add(-time.Hour),
+			NextUpdate:                time.Now().Add(time.Hour),
+			RevokedCertificateEntries: entries,
+		}, ca, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	var responderStatus int
+	var crlBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/crl" {
+			w.Write(crlBody)
+			return
+		}
+		w.Write(ocspResponse(responderStatus))
+	}))
+	defer srv.Close()
+
+	var staple []byte
+	config := &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &tls.Certificate{
+				Certificate: [][]byte{leafDER, caDER},
+				PrivateKey:  crypto.Signer(leafKey),
+				OCSPStaple:  staple,
+			}, nil
+		},
+	}
+	ln, err := tls.Listen("tcp", "localhost:0", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				break
+			}
+			go func(conn net.Conn) {
+				_, _ = conn.Read(nil)
+				_ = conn.Close()
+			}(conn)
+		}
+	}()
+
+	for i, test := range []struct {
+		checker   Checker
+		staple    []byte
+		responder int
+		crl       []byte
+		status    string
+		notice    string
+	}{
+		{Checker{Revocation: RevocationStaple}, ocspResponse(ocsp.Good), 0, nil, "healthy", ""},
+		{Checker{Revocation: RevocationStaple}, ocspResponse(ocsp.Revoked), 0, nil, "down", "certificate revoked at"},
+		{Checker{Revocation: RevocationStaple, OnRevoked: ActionDegraded}, ocspResponse(ocsp.Revoked), 0, nil, "degraded", "certificate revoked at"},
+		{Checker{Revocation: RevocationStaple}, ocspResponse(ocsp.Unknown), 0, nil, "degraded", "OCSP status unknown"},
+		{Checker{Revocation: RevocationStaple}, nil, 0, nil, "degraded", "no OCSP response stapled"},
+		{Checker{Revocation: RevocationStaple, OnMissingStaple: ActionIgnore}, nil, 0, nil, "healthy", ""},
+		{Checker{Revocation: RevocationStaple, OnMissingStaple: ActionDown}, nil, 0, nil, "down", "no OCSP response stapled"},
+		{Checker{Revocation: RevocationOCSP, OCSPURL: srv.URL}, nil, ocsp.Good, nil, "healthy", ""},
+		{Checker{Revocation: RevocationOCSP, OCSPURL: srv.URL}, nil, ocsp.Revoked, nil, "down", "certificate revoked at"},
+		{Checker{Revocation: RevocationOCSP}, nil, ocsp.Good, nil, "degraded", "could not query OCSP responder"},
+		{Checker{Revocation: RevocationCRL, CRLURL: srv.URL + "/crl"}, nil, 0, crl(big.NewInt(5)), "healthy", ""},
+		{Checker{Revocation: RevocationCRL, CRLURL: srv.URL + "/crl"}, nil, 0, crl(leaf.SerialNumber), "down", "certificate revoked at"},
+		{Checker{Revocation: RevocationCRL, CRLURL: srv.URL + "/crl"}, nil, 0, []byte("garbage"), "degraded", "invalid CRL"},
+	} {
+		staple = test.staple
+		responderStatus = test.responder
+		crlBody = test.crl
+
+		tc := test.checker
+		tc.URL = ln.Addr().String()
+		tc.Timeout = 5 * time.Second
+		tc.tlsConfig = &tls.Config{RootCAs: x509.NewCertPool(), ServerName: "localhost"}
+		tc.tlsConfig.RootCAs.AddCert(ca)
+
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+	}
+
+	// without the issuer, signatures can't be verified
+	crlBody = crl()
+	tc := Checker{CRLURL: srv.URL + "/crl", Timeout: 5 * time.Second}
+	if status, msg := tc.checkCRL(leaf, nil); status != revocationUnknown {
+		t.Errorf("Expected unknown CRL status without the issuer, got %v: %s", status, msg)
+	}
+	state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}, OCSPResponse: ocspResponse(ocsp.Good)}
+	if status, msg := tc.checkRevocation(state); status != revocationUnknown {
+		t.Errorf("Expected unknown OCSP status without the issuer, got %v: %s", status, msg)
+	}
+
+	tc = Checker{URL: ln.Addr().String(), Revocation: "psychic"}
+	if _, err := tc.Check(); err == nil {
+		t.Error("Expected an error for an unknown revocation mode, didn't get one")
+	}
+}
@@ -0,0 +1,274 @@
+package tls
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// startTLSFunc performs the protocol-specific upgrade to TLS
+// on conn, after which the TLS handshake can begin. host is
+// the name of the server.
+type startTLSFunc func(conn net.Conn, host string) error
+
+// startTLS maps the protocols supported by Checker.StartTLS to
+// their upgrade functions.
+var startTLS = map[string]startTLSFunc{
+	"smtp":     startTLSSMTP,
+	"imap":     startTLSIMAP,
+	"pop3":     startTLSPOP3,
+	"ftp":      startTLSFTP,
+	"ldap":     startTLSLDAP,
+	"xmpp":     startTLSXMPP,
+	"postgres": startTLSPostgres,
+}
+
+// startTLSSMTP issues EHLO and STARTTLS (RFC 3207).
+func startTLSSMTP(conn net.Conn, host string) error {
+	r := bufio.NewReader(conn)
+	if err := expectReply(r, "220"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(conn, "EHLO checkup\r\n"); err != nil {
+		return err
+	}
+	if err := expectReply(r, "250"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(conn, "STARTTLS\r\n"); err != nil {
+		return err
+	}
+	return expectReply(r, "220")
+}
+
+// startTLSFTP issues AUTH TLS (RFC 4217).
+func startTLSFTP(conn net.Conn, host string) error {
+	r := bufio.NewReader(conn)
+	if err := expectReply(r, "220"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(conn, "AUTH TLS\r\n"); err != nil {
+		return err
+	}
+	return expectReply(r, "234")
+}
+
+// expectReply reads a possibly multi-line SMTP or FTP reply
+// from r and returns an error if its code isn't code.
+func expectReply(r *bufio.Reader, code string) error {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("starttls: reading reply: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) < 3 || line[:3] != code {
+			return fmt.Errorf("starttls: unexpected reply: %s", line)
+		}
+		if len(line) == 3 || line[3] != '-' {
+			return nil
+		}
+	}
+}
+
+// startTLSIMAP issues STARTTLS (RFC 3501).
+func startTLSIMAP(conn net.Conn, host string) error {
+	r := bufio.NewReader(conn)
+	line, err := readLine(r)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "* OK") {
+		return fmt.Errorf("starttls: unexpected greeting: %s", line)
+	}
+	if _, err := fmt.Fprintf(conn, "a001 STARTTLS\r\n"); err != nil {
+		return err
+	}
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(line, "a001 ") {
+			if !strings.HasPrefix(line, "a001 OK") {
+				return fmt.Errorf("starttls: unexpected reply: %s", line)
+			}
+			return nil
+		}
+	}
+}
+
+// startTLSPOP3 issues STLS (RFC 2595).
+func startTLSPOP3(conn net.Conn, host string) error {
+	r := bufio.NewReader(conn)
+	line, err := readLine(r)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return fmt.Errorf("starttls: unexpected greeting: %s", line)
+	}
+	if _, err := fmt.Fprintf(conn, "STLS\r\n"); err != nil {
+		return err
+	}
+	line, err = readLine(r)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return fmt.Errorf("starttls: unexpected reply: %s", line)
+	}
+	return nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("starttls: reading reply: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// ldapStartTLSRequest is an LDAP ExtendedRequest with message
+// ID 1 for the StartTLS operation (RFC 4511, section 4.14).
+var ldapStartTLSRequest = append([]byte{
+	0x30, 0x1d, // LDAPMessage
+	0x02, 0x01, 0x01, // messageID 1
+	0x77, 0x18, // [APPLICATION 23] ExtendedRequest
+	0x80, 0x16, // [0] requestName
+}, "1.3.6.1.4.1.1466.20037"...)
+
+// startTLSLDAP issues the StartTLS extended operation.
+func startTLSLDAP(conn net.Conn, host string) error {
+	if _, err := conn.Write(ldapStartTLSRequest); err != nil {
+		return err
+	}
+	r := bufio.NewReader(conn)
+	msg, err := readBER(r, 0x30)
+	if err != nil {
+		return err
+	}
+	msgReader := bufio.NewReader(bytes.NewReader(msg))
+	if _, err := readBER(msgReader, 0x02); err != nil {
+		return err
+	}
+	resp, err := readBER(msgReader, 0x78)
+	if err != nil {
+		return err
+	}
+	code, err := readBER(bufio.NewReader(bytes.NewReader(resp)), 0x0a)
+	if err != nil {
+		return err
+	}
+	if len(code) != 1 || code[0] != 0 {
+		return fmt.Errorf("starttls: LDAP result code %v", code)
+	}
+	return nil
+}
+
+// readBER reads a BER element with the given tag from r and
+// returns its contents.
+func readBER(r *bufio.Reader, tag byte) ([]byte, error) {
+	t, err := r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("starttls: reading LDAP response: %w", err)
+	}
+	if t != tag {
+		return nil, fmt.Errorf("starttls: unexpected LDAP tag 0x%02x", t)
+	}
+	l, err := r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("starttls: reading LDAP response: %w", err)
+	}
+	length := int(l)
+	if l&0x80 != 0 {
+		n := int(l & 0x7f)
+		if n == 0 || n > 4 {
+			return nil, fmt.Errorf("starttls: invalid LDAP length")
+		}
+		length = 0
+		for i := 0; i < n; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, fmt.Errorf("starttls: reading LDAP response: %w", err)
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	contents := make([]byte, length)
+	if _, err := io.ReadFull(r, contents); err != nil {
+		return nil, fmt.Errorf("starttls: reading LDAP response: %w", err)
+	}
+	return contents, nil
+}
+
+// startTLSXMPP opens a client stream and negotiates STARTTLS
+// (RFC 6120, section 5).
+func startTLSXMPP(conn net.Conn, host string) error {
+	_, err := fmt.Fprintf(conn, "<?xml version='1.0'?><stream:stream to='%s' version='1.0' "+
+		"xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams'>", host)
+	if err != nil {
+		return err
+	}
+	features, err := readUntil(conn, "</stream:features>")
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(features, "urn:ietf:params:xml:ns:xmpp-tls") {
+		return fmt.Errorf("starttls: XMPP server does not offer STARTTLS")
+	}
+	if _, err := fmt.Fprintf(conn, "<starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>"); err != nil {
+		return err
+	}
+	reply, err := readUntil(conn, "/>")
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(reply, "<proceed") {
+		return fmt.Errorf("starttls: unexpected reply: %s", reply)
+	}
+	return nil
+}
+
+// readUntil reads from r byte by byte until the data read ends
+// with suffix, so that nothing past it is consumed.
+func readUntil(r io.Reader, suffix string) (string, error) {
+	var buf []byte
+	b := make([]byte, 1)
+	for len(buf) < 64*1024 {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return "", fmt.Errorf("starttls: reading reply: %w", err)
+		}
+		buf = append(buf, b[0])
+		if bytes.HasSuffix(buf, []byte(suffix)) {
+			return string(buf), nil
+		}
+	}
+	return "", fmt.Errorf("starttls: reply too long")
+}
+
+// postgresSSLRequestCode is the code of the SSLRequest message.
+const postgresSSLRequestCode = 80877103
+
+// startTLSPostgres sends an SSLRequest and expects the server
+// to accept it.
+func startTLSPostgres(conn net.Conn, host string) error {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint32(msg[0:4], 8)
+	binary.BigEndian.PutUint32(msg[4:8], postgresSSLRequestCode)
+	if _, err := conn.Write(msg); err != nil {
+		return err
+	}
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(conn, b); err != nil {
+		return fmt.Errorf("starttls: reading reply: %w", err)
+	}
+	if b[0] != 'S' {
+		return fmt.Errorf("starttls: PostgreSQL server refused SSL")
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package tls
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckerStartTLS(t *testing.T) {
+	cert, err := makeSelfSignedCert("localhost", "", time.Hour*24*30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	// each server speaks the plaintext part of its protocol
+	// and returns whether the client asked to upgrade
+	servers := map[string]func(conn net.Conn, r *bufio.Reader) bool{
+		"smtp": func(conn net.Conn, r *bufio.Reader) bool {
+			fmt.Fprintf(conn, "220-localhost ESMTP\r\n220 ready\r\n")
+			if line, _ := r.ReadString('\n'); !strings.HasPrefix(line, "EHLO ") {
+				return false
+			}
+			fmt.Fprintf(conn, "250-localhost\r\n250-PIPELINING\r\n250 STARTTLS\r\n")
+			if line, _ := r.ReadString('\n'); line != "STARTTLS\r\n" {
+				return false
+			}
+			fmt.Fprintf(conn, "220 go ahead\r\n")
+			return true
+		},
+		"imap": func(conn net.Conn, r *bufio.Reader) bool {
+			fmt.Fprintf(conn, "* OK [CAPABILITY IMAP4rev1 STARTTLS] ready\r\n")
+			if line, _ := r.ReadString('\n'); line != "a001 STARTTLS\r\n" {
+				return false
+			}
+			fmt.Fprintf(conn, "a001 OK Begin TLS negotiation now\r\n")
+			return true
+		},
+		"pop3": func(conn net.Conn, r *bufio.Reader) bool {
+			fmt.Fprintf(conn, "+OK POP3 ready\r\n")
+			if line, _ := r.ReadString('\n'); line != "STLS\r\n" {
+				return false
+			}
+			fmt.Fprintf(conn, "+OK Begin TLS negotiation\r\n")
+			return true
+		},
+		"ftp": func(conn net.Conn, r *bufio.Reader) bool {
+			fmt.Fprintf(conn, "220 FTP ready\r\n")
+			if line, _ := r.ReadString('\n'); line != "AUTH TLS\r\n" {
+				return false
+			}
+			fmt.Fprintf(conn, "234 AUTH TLS successful\r\n")
+			return true
+		},
+		"ldap": func(conn net.Conn, r *bufio.Reader) bool {
+			req := make([]byte, len(ldapStartTLSRequest))
+			if _, err := io.ReadFull(r, req); err != nil || !bytes.Equal(req, ldapStartTLSRequest) {
+				return false
+			}
+			// ExtendedResponse with resultCode success
+			conn.Write([]byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x78, 0x07, 0x0a, 0x01, 0x00, 0x04, 0x00, 0x04, 0x00})
+			return true
+		},
+		"xmpp": func(conn net.Conn, r *bufio.Reader) bool {
+			if _, err := readUntil(r, "'>"); err != nil {
+				return false
+			}
+			fmt.Fprintf(conn, "<stream:stream from='localhost' version='1.0'><stream:features>"+
+				"<starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'><required/></starttls></stream:features>")
+			if _, err := readUntil(r, "/>"); err != nil {
+				return false
+			}
+			fmt.Fprintf(conn, "<proceed xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>")
+			return true
+		},
+		"postgres": func(conn net.Conn, r *bufio.Reader) bool {
+			req := make([]byte, 8)
+			if _, err := io.ReadFull(r, req); err != nil || !bytes.Equal(req, []byte{0, 0, 0, 8, 4, 210, 22, 47}) {
+				return false
+			}
+			conn.Write([]byte("S"))
+			return true
+		},
+	}
+
+	for protocol, serve := range servers {
+		ln, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func(serve func(net.Conn, *bufio.Reader) bool) {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				if serve(conn, bufio.NewReader(conn)) {
+					tlsConn := tls.Server(conn, config)
+					_ = tlsConn.Handshake()
+					_, _ = tlsConn.Read(nil)
+				}
+				_ = conn.Close()
+			}
+		}(serve)
+
+		tc := Checker{
+			Name:      protocol,
+			URL:       ln.Addr().String(),
+			StartTLS:  protocol,
+			Timeout:   5 * time.Second,
+			Attempts:  2,
+			tlsConfig: &tls.Config{RootCAs: x509.NewCertPool(), ServerName: "localhost"},
+		}
+		tc.tlsConfig.RootCAs.AddCert(cert.Leaf)
+
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("%s: Didn't expect an error: %v", protocol, err)
+		}
+		if !result.Healthy {
+			t.Errorf("%s: Expected result.Healthy=true, got %s: %v", protocol, result.Status(), result.Times)
+		}
+
+		// a server that doesn't speak the protocol is down
+		tc.Timeout = 200 * time.Millisecond
+		tc.StartTLS = "smtp"
+		if protocol == "smtp" {
+			tc.StartTLS = "pop3"
+		}
+		result, err = tc.Check()
+		if err != nil {
+			t.Errorf("%s: Didn't expect an error: %v", protocol, err)
+		}
+		if !result.Down {
+			t.Errorf("%s: Expected result.Down=true with %s, got %s", protocol, tc.StartTLS, result.Status())
+		}
+
+		ln.Close()
+	}
+
+	tc := Checker{URL: "localhost:0", StartTLS: "gopher"}
+	if _, err := tc.Check(); err == nil {
+		t.Error("Expected an error for an unknown starttls protocol, didn't get one")
+	}
+}
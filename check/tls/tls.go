@@ -1,12 +1,18 @@
 package tls
 
 import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
+	"strings"
 	"time"
 
 	"github.com/sourcegraph/checkup/types"
@@ -15,12 +21,20 @@ import (
 // Type should match the package name
 const Type = "tls"
 
+// DefaultCertExpiryThreshold is how close to expiration a
+// certificate must be before declaring a degraded status, if
+// no threshold is configured.
+const DefaultCertExpiryThreshold = 14 * 24 * time.Hour
+
 // Checker implements a Checker for TLS endpoints.
 //
+// Every certificate presented by the endpoint is checked for
+// expiry. Optionally, the protocol version, cipher suite and
+// certificates can be asserted against a policy; violations
+// mark the endpoint as down. The revocation status of the
+// leaf certificate can be checked with OCSP or a CRL.
+//
 // TODO: Implement more checks on the certificate and TLS configuration.
-//  - Cipher suites
-//  - Protocol versions
-//  - OCSP stapling
 //  - Multiple SNIs
 //  - Other things that you might see at SSL Labs or other TLS health checks
 type Checker struct {
@@ -30,6 +44,9 @@ type Checker struct {
 	// URL is the host:port of the remote endpoint to check.
 	URL string `json:"endpoint_url"`
 
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
 	// Timeout is the maximum time to wait for a
 	// TLS connection to be established.
 	Timeout time.Duration `json:"timeout,omitempty"`
@@ -48,13 +65,94 @@ type Checker struct {
 
 	// CertExpiryThreshold is how close to expiration
 	// the TLS certificate must be before declaring
-	// a degraded status. Default is 14 days.
+	// a degraded status. Default is
+	// DefaultCertExpiryThreshold (14 days).
 	CertExpiryThreshold time.Duration `json:"cert_expiry_threshold,omitempty"`
 
 	// TrustedRoots is a list of PEM files to load as
 	// trusted root CAs when connecting to TLS remotes.
 	TrustedRoots []string `json:"trusted_roots,omitempty"`
 
+	// StartTLS is the protocol with which to upgrade a
+	// plaintext connection to TLS before the handshake:
+	// "smtp", "imap", "pop3", "ftp", "ldap", "xmpp" or
+	// "postgres". If empty, the handshake starts as soon
+	// as the connection is established.
+	StartTLS string `json:"starttls,omitempty"`
+
+	// ServerName is the name used for SNI and to verify
+	// the hostname of the certificate. Default is the
+	// host from URL.
+	ServerName string `json:"server_name,omitempty"`
+
+	// MinVersion is the minimum TLS version, such as
+	// "1.2", that the endpoint must negotiate.
+	MinVersion string `json:"min_version,omitempty"`
+
+	// CipherSuites is a list of cipher suite names, such
+	// as "TLS_AES_128_GCM_SHA256", one of which the
+	// endpoint must negotiate. If empty, any cipher suite
+	// is allowed.
+	CipherSuites []string `json:"cipher_suites,omitempty"`
+
+	// ExpectedSANs are names or IP addresses that must
+	// all be among the subject alternative names of the
+	// leaf certificate.
+	ExpectedSANs []string `json:"expected_sans,omitempty"`
+
+	// ExpectedIssuer must equal the common name or the
+	// full distinguished name of the issuer of the leaf
+	// certificate, if set.
+	ExpectedIssuer string `json:"expected_issuer,omitempty"`
+
+	// Fingerprints are hex-encoded SHA-256 fingerprints,
+	// one of which the leaf certificate must match, if
+	// set. Colons are ignored.
+	Fingerprints []string `json:"fingerprints,omitempty"`
+
+	// MinRSABits is the minimum size in bits of RSA keys
+	// in the certificate chain.
+	MinRSABits int `json:"min_rsa_bits,omitempty"`
+
+	// MinECDSABits is the minimum size in bits of ECDSA
+	// keys in the certificate chain.
+	MinECDSABits int `json:"min_ecdsa_bits,omitempty"`
+
+	// SignatureAlgorithms is a list of signature algorithm
+	// names, such as "SHA256-RSA" or "ECDSA-SHA256", that
+	// the certificates in the chain must be signed with.
+	// If empty, any signature algorithm is allowed.
+	SignatureAlgorithms []string `json:"signature_algorithms,omitempty"`
+
+	// Revocation enables checking whether the leaf
+	// certificate has been revoked: RevocationStaple,
+	// RevocationOCSP or RevocationCRL. If empty,
+	// revocation is not checked.
+	Revocation string `json:"revocation,omitempty"`
+
+	// OCSPURL overrides the OCSP responder URL from
+	// the certificate.
+	OCSPURL string `json:"ocsp_url,omitempty"`
+
+	// CRLURL overrides the CRL distribution point
+	// from the certificate.
+	CRLURL string `json:"crl_url,omitempty"`
+
+	// OnRevoked is the action to take when the
+	// certificate is revoked: ActionDown (default),
+	// ActionDegraded or ActionIgnore.
+	OnRevoked string `json:"on_revoked,omitempty"`
+
+	// OnUnknown is the action to take when the
+	// revocation status can't be determined. Default
+	// is ActionDegraded.
+	OnUnknown string `json:"on_unknown,omitempty"`
+
+	// OnMissingStaple is the action to take when no
+	// OCSP response is stapled in RevocationStaple
+	// mode. Default is ActionDegraded.
+	OnMissingStaple string `json:"on_missing_staple,omitempty"`
+
 	// tlsConfig is the config to use when making a TLS
 	// connection. Values in this struct take precedence
 	// over values described from the JSON (exported)
@@ -74,6 +172,16 @@ func (Checker) Type() string {
 	return Type
 }
 
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
 // Check performs checks using c according to its configuration.
 // An error is only returned if there is a configuration error.
 func (c Checker) Check() (types.Result, error) {
@@ -81,13 +189,48 @@ func (c Checker) Check() (types.Result, error) {
 		c.Attempts = 1
 	}
 	if c.CertExpiryThreshold == 0 {
-		c.CertExpiryThreshold = 24 * time.Hour * 14
+		c.CertExpiryThreshold = DefaultCertExpiryThreshold
 	}
 
-	if len(c.TrustedRoots) > 0 {
-		if c.tlsConfig == nil {
-			c.tlsConfig = new(tls.Config)
+	if _, ok := startTLS[c.StartTLS]; c.StartTLS != "" && !ok {
+		return types.Result{}, fmt.Errorf("unknown starttls protocol: %s", c.StartTLS)
+	}
+
+	switch c.Revocation {
+	case "", RevocationStaple, RevocationOCSP, RevocationCRL:
+	default:
+		return types.Result{}, fmt.Errorf("unknown revocation mode: %s", c.Revocation)
+	}
+	for _, action := range []string{c.OnRevoked, c.OnUnknown, c.OnMissingStaple} {
+		switch action {
+		case "", ActionDown, ActionDegraded, ActionIgnore:
+		default:
+			return types.Result{}, fmt.Errorf("unknown revocation action: %s", action)
 		}
+	}
+
+	c.tlsConfig = c.tlsConfig.Clone()
+	if c.tlsConfig == nil {
+		c.tlsConfig = new(tls.Config)
+	}
+	if c.ServerName != "" {
+		c.tlsConfig.ServerName = c.ServerName
+	}
+	if c.tlsConfig.ServerName == "" {
+		if host, _, err := net.SplitHostPort(c.URL); err == nil {
+			c.tlsConfig.ServerName = host
+		}
+	}
+	if c.MinVersion != "" {
+		if _, ok := versions[c.MinVersion]; !ok {
+			return types.Result{}, fmt.Errorf("unknown TLS version: %s", c.MinVersion)
+		}
+		// allow older versions to be negotiated, so that
+		// they can be reported clearly
+		c.tlsConfig.MinVersion = tls.VersionTLS10
+	}
+
+	if len(c.TrustedRoots) > 0 {
 		if c.tlsConfig.RootCAs == nil {
 			c.tlsConfig.RootCAs = x509.NewCertPool()
 		}
@@ -107,6 +250,7 @@ func (c Checker) Check() (types.Result, error) {
 	result := types.NewResult()
 	result.Title = c.Name
 	result.Endpoint = c.URL
+	result.Tags = c.Tags
 	result.Times = attempts
 	result.ThresholdRTT = c.ThresholdRTT
 
@@ -122,9 +266,8 @@ func (c Checker) doChecks() (types.Attempts, []*tls.Conn) {
 	checks := make(types.Attempts, c.Attempts)
 	conns := make([]*tls.Conn, c.Attempts)
 	for i := 0; i < c.Attempts; i++ {
-		dialer := &net.Dialer{Timeout: c.Timeout}
 		start := time.Now()
-		conn, err := tls.DialWithDialer(dialer, "tcp", c.URL, c.tlsConfig)
+		conn, err := c.dial()
 		checks[i].RTT = time.Since(start)
 		conns[i] = conn
 		if err != nil {
@@ -135,6 +278,42 @@ func (c Checker) doChecks() (types.Attempts, []*tls.Conn) {
 	return checks, conns
 }
 
+// dial establishes a TLS connection to the endpoint, first
+// upgrading a plaintext connection if c.StartTLS is set.
+func (c Checker) dial() (*tls.Conn, error) {
+	dialer := &net.Dialer{Timeout: c.Timeout}
+	if c.StartTLS == "" {
+		return tls.DialWithDialer(dialer, "tcp", c.URL, c.tlsConfig)
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	rawConn, err := dialer.Dial("tcp", c.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err := rawConn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	if err := startTLS[c.StartTLS](rawConn, c.tlsConfig.ServerName); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	conn := tls.Client(rawConn, c.tlsConfig)
+	if err := conn.Handshake(); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	if err := rawConn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
 // conclude takes the data in result from the attempts and
 // computes remaining values needed to fill out the result.
 // It detects less-than-ideal (degraded) connections and
@@ -158,22 +337,37 @@ func (c Checker) conclude(conns []*tls.Conn, result types.Result) types.Result {
 		}
 	}
 
-	// check if certificates expired (down)
+	// check if certificates expired or violate policy (down)
 	for i, conn := range conns {
 		if conn == nil {
 			continue
 		}
-		serverCerts := conn.ConnectionState().PeerCertificates
-		if len(serverCerts) == 0 {
-			result.Times[i].Error = "no certificates presented"
+		if err := c.checkDown(conn.ConnectionState()); err != nil {
+			result.Times[i].Error = err.Error()
+			result.Notice = err.Error()
 			result.Down = true
 			return result
 		}
-		leaf := serverCerts[0]
-		if leaf.NotAfter.Before(time.Now()) {
-			result.Times[i].Error = fmt.Sprintf("certificate expired %s ago", time.Since(leaf.NotAfter))
-			result.Down = true
-			return result
+	}
+
+	// check revocation status (down or degraded)
+	if c.Revocation != "" {
+		for _, conn := range conns {
+			if conn == nil {
+				continue
+			}
+			status, msg := c.checkRevocation(conn.ConnectionState())
+			switch c.action(status) {
+			case ActionDown:
+				result.Notice = msg
+				result.Down = true
+				return result
+			case ActionDegraded:
+				result.Notice = msg
+				result.Degraded = true
+				return result
+			}
+			break
 		}
 	}
 
@@ -182,12 +376,12 @@ func (c Checker) conclude(conns []*tls.Conn, result types.Result) types.Result {
 		if conn == nil {
 			continue
 		}
-		serverCerts := conn.ConnectionState().PeerCertificates
-		leaf := serverCerts[0]
-		if until := time.Until(leaf.NotAfter); until < c.CertExpiryThreshold {
-			result.Notice = fmt.Sprintf("certificate expiring soon (%s)", until)
-			result.Degraded = true
-			return result
+		for i, cert := range conn.ConnectionState().PeerCertificates {
+			if until := time.Until(cert.NotAfter); until < c.CertExpiryThreshold {
+				result.Notice = fmt.Sprintf("%s expiring soon (%s)", describe(i, cert), until)
+				result.Degraded = true
+				return result
+			}
 		}
 	}
 
@@ -204,3 +398,126 @@ func (c Checker) conclude(conns []*tls.Conn, result types.Result) types.Result {
 	result.Healthy = true
 	return result
 }
+
+// checkDown checks whether the connection described by state
+// presents expired certificates or violates the policy of c.
+// It returns a non-nil error if down.
+func (c Checker) checkDown(state tls.ConnectionState) error {
+	certs := state.PeerCertificates
+	if len(certs) == 0 {
+		return errors.New("no certificates presented")
+	}
+	for i, cert := range certs {
+		if cert.NotAfter.Before(time.Now()) {
+			return fmt.Errorf("%s expired %s ago", describe(i, cert), time.Since(cert.NotAfter))
+		}
+	}
+
+	if c.MinVersion != "" && state.Version < versions[c.MinVersion] {
+		return fmt.Errorf("negotiated %s, below minimum TLS %s", versionName(state.Version), c.MinVersion)
+	}
+	if len(c.CipherSuites) > 0 {
+		name := tls.CipherSuiteName(state.CipherSuite)
+		if !contains(c.CipherSuites, name) {
+			return fmt.Errorf("negotiated cipher suite %s is not allowed", name)
+		}
+	}
+
+	leaf := certs[0]
+	for _, san := range c.ExpectedSANs {
+		if !hasSAN(leaf, san) {
+			return fmt.Errorf("certificate does not include subject alternative name %s", san)
+		}
+	}
+	if c.ExpectedIssuer != "" &&
+		c.ExpectedIssuer != leaf.Issuer.CommonName && c.ExpectedIssuer != leaf.Issuer.String() {
+		return fmt.Errorf("certificate issued by %s, expected %s", leaf.Issuer, c.ExpectedIssuer)
+	}
+	if len(c.Fingerprints) > 0 {
+		sum := sha256.Sum256(leaf.Raw)
+		fingerprint := hex.EncodeToString(sum[:])
+		matched := false
+		for _, want := range c.Fingerprints {
+			if strings.EqualFold(strings.Replace(want, ":", "", -1), fingerprint) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("certificate fingerprint %s does not match", fingerprint)
+		}
+	}
+
+	for i, cert := range certs {
+		switch key := cert.PublicKey.(type) {
+		case *rsa.PublicKey:
+			if bits := key.N.BitLen(); bits < c.MinRSABits {
+				return fmt.Errorf("%s has %d-bit RSA key, below minimum %d", describe(i, cert), bits, c.MinRSABits)
+			}
+		case *ecdsa.PublicKey:
+			if bits := key.Curve.Params().BitSize; bits < c.MinECDSABits {
+				return fmt.Errorf("%s has %d-bit ECDSA key, below minimum %d", describe(i, cert), bits, c.MinECDSABits)
+			}
+		}
+		if len(c.SignatureAlgorithms) > 0 && !contains(c.SignatureAlgorithms, cert.SignatureAlgorithm.String()) {
+			return fmt.Errorf("%s is signed with %s, which is not allowed", describe(i, cert), cert.SignatureAlgorithm)
+		}
+	}
+
+	return nil
+}
+
+// describe returns a description of cert, the i'th certificate
+// in a chain, for use in notices.
+func describe(i int, cert *x509.Certificate) string {
+	if i == 0 {
+		return "certificate"
+	}
+	return fmt.Sprintf("chain certificate %q", cert.Subject.CommonName)
+}
+
+// hasSAN returns whether cert includes san as a DNS name or
+// IP address.
+func hasSAN(cert *x509.Certificate, san string) bool {
+	if ip := net.ParseIP(san); ip != nil {
+		for _, addr := range cert.IPAddresses {
+			if addr.Equal(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, name := range cert.DNSNames {
+		if strings.EqualFold(name, san) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// versions maps the names of TLS versions to their values.
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// versionName returns the name of TLS version v.
+func versionName(v uint16) string {
+	for name, version := range versions {
+		if version == v {
+			return "TLS " + name
+		}
+	}
+	return fmt.Sprintf("unknown TLS version 0x%04x", v)
+}
@@ -5,12 +5,15 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 	"testing"
 	"time"
 )
@@ -128,6 +131,74 @@ func TestChecker(t *testing.T) {
 	}
 }
 
+func TestCheckerPolicy(t *testing.T) {
+	cert, err := makeSelfSignedCert("localhost", "rsa2048", time.Hour*24*30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endpt := "localhost:4044"
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MaxVersion: tls.VersionTLS12}
+	ln, err := tls.Listen("tcp", endpt, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				break
+			}
+			_, _ = conn.Read(nil)
+			_ = conn.Close()
+		}
+	}()
+
+	sum := sha256.Sum256(cert.Leaf.Raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	for i, test := range []struct {
+		checker Checker
+		notice  string
+	}{
+		{Checker{MinVersion: "1.2", ExpectedSANs: []string{"localhost"}, ExpectedIssuer: "O=Checkup Test",
+			Fingerprints: []string{strings.ToUpper(fingerprint)}, MinRSABits: 2048, SignatureAlgorithms: []string{"SHA256-RSA"}}, ""},
+		{Checker{MinVersion: "1.3"}, "negotiated TLS 1.2, below minimum TLS 1.3"},
+		{Checker{CipherSuites: []string{"TLS_AES_128_GCM_SHA256"}}, "negotiated cipher suite"},
+		{Checker{ExpectedSANs: []string{"localhost", "example.com"}}, "certificate does not include subject alternative name example.com"},
+		{Checker{ExpectedIssuer: "Let's Encrypt"}, "certificate issued by O=Checkup Test, expected Let's Encrypt"},
+		{Checker{Fingerprints: []string{"00:11"}}, "certificate fingerprint " + fingerprint + " does not match"},
+		{Checker{MinRSABits: 4096}, "certificate has 2048-bit RSA key, below minimum 4096"},
+		{Checker{SignatureAlgorithms: []string{"ECDSA-SHA256"}}, "certificate is signed with SHA256-RSA, which is not allowed"},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.URL = endpt
+		tc.tlsConfig = &tls.Config{RootCAs: x509.NewCertPool()}
+		tc.tlsConfig.RootCAs.AddCert(cert.Leaf)
+
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if test.notice == "" {
+			if !result.Healthy {
+				t.Errorf("Test %d: Expected result.Healthy=true, got %s: %s", i, result.Status(), result.Notice)
+			}
+			continue
+		}
+		if !result.Down {
+			t.Errorf("Test %d: Expected result.Down=true, got %s", i, result.Status())
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+	}
+}
+
 func makeSelfSignedCert(hostname, keyType string, validity time.Duration) (tls.Certificate, error) {
 	// start by generating private key
 	var privKey interface{}
@@ -0,0 +1,300 @@
+package websocket
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "websocket"
+
+// Checker implements a Checker for WebSocket endpoints.
+//
+// Each attempt performs the opening handshake, optionally
+// sends a message and waits for the reply, then closes the
+// connection cleanly. The median handshake and message round
+// trip times are reported as the "handshake" and "round_trip"
+// metrics of the result.
+type Checker struct {
+	// Name is the name of the endpoint.
+	Name string `json:"endpoint_name"`
+
+	// URL is the ws:// or wss:// URL of the endpoint.
+	URL string `json:"endpoint_url"`
+
+	// Tags are attached to the result.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Headers contains headers to add to the handshake
+	// request, such as Origin or Authorization.
+	Headers http.Header `json:"headers,omitempty"`
+
+	// Subprotocols are the subprotocols to request, in
+	// order of preference. If set, the server must
+	// accept one of them.
+	Subprotocols []string `json:"subprotocols,omitempty"`
+
+	// Message is a text message to send once connected.
+	// If empty, no message is sent.
+	Message string `json:"message,omitempty"`
+
+	// MustContain is a string that the reply to Message
+	// must contain in order to be considered up.
+	MustContain string `json:"must_contain,omitempty"`
+
+	// MustNotContain is a string that the reply to
+	// Message must NOT contain in order to be considered
+	// up.
+	MustNotContain string `json:"must_not_contain,omitempty"`
+
+	// TLSSkipVerify controls whether to skip server TLS
+	// certificate validation or not.
+	TLSSkipVerify bool `json:"tls_skip_verify,omitempty"`
+
+	// Timeout is the maximum time to wait for the
+	// handshake, and then for the reply to Message.
+	// Default is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// ThresholdRTT is the maximum round trip time to
+	// allow for a healthy endpoint. If non-zero and a
+	// request takes longer than ThresholdRTT, the
+	// endpoint will be considered unhealthy. Note that
+	// this duration includes any in-between network
+	// latency.
+	ThresholdRTT time.Duration `json:"threshold_rtt,omitempty"`
+
+	// Attempts is how many requests the client will
+	// make to the endpoint in a single check.
+	Attempts int `json:"attempts,omitempty"`
+
+	// AttemptSpacing spaces out each attempt in a check
+	// by this duration to avoid hitting a remote too
+	// quickly in succession. By default, no waiting
+	// occurs between attempts.
+	AttemptSpacing time.Duration `json:"attempt_spacing,omitempty"`
+}
+
+// New creates a new Checker instance based on json config
+func New(config json.RawMessage) (Checker, error) {
+	var checker Checker
+	err := json.Unmarshal(config, &checker)
+	return checker, err
+}
+
+// Type returns the checker package name
+func (Checker) Type() string {
+	return Type
+}
+
+// Title returns the title of the result
+func (c Checker) Title() string {
+	return c.Name
+}
+
+// ResultTags returns the tags attached to the result
+func (c Checker) ResultTags() map[string]string {
+	return c.Tags
+}
+
+// Check performs checks using c according to its configuration.
+// An error is only returned if there is a configuration error.
+func (c Checker) Check() (types.Result, error) {
+	if c.Attempts < 1 {
+		c.Attempts = 1
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return types.Result{}, err
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return types.Result{}, fmt.Errorf("websocket: URL scheme must be ws or wss: %s", c.URL)
+	}
+
+	result := types.NewResult()
+	result.Title = c.Name
+	result.Endpoint = c.URL
+	result.Tags = c.Tags
+	var handshakes, roundTrips []time.Duration
+	result.Times, handshakes, roundTrips = c.doChecks()
+	result.Metrics = metrics(handshakes, roundTrips)
+
+	return c.conclude(result), nil
+}
+
+// doChecks executes the checks and returns each attempt,
+// along with the handshake and message round trip times of
+// the successful ones.
+func (c Checker) doChecks() (types.Attempts, []time.Duration, []time.Duration) {
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: c.Timeout,
+		Subprotocols:     c.Subprotocols,
+		TLSClientConfig:  &tls.Config{InsecureSkipVerify: c.TLSSkipVerify},
+	}
+
+	checks := make(types.Attempts, c.Attempts)
+	var handshakes, roundTrips []time.Duration
+	for i := 0; i < c.Attempts; i++ {
+		rtt, handshake, roundTrip, err := c.attempt(dialer)
+		checks[i].RTT = rtt
+		if err != nil {
+			checks[i].Error = err.Error()
+		} else {
+			handshakes = append(handshakes, handshake)
+			if c.Message != "" {
+				roundTrips = append(roundTrips, roundTrip)
+			}
+		}
+
+		if c.AttemptSpacing > 0 {
+			time.Sleep(c.AttemptSpacing)
+		}
+	}
+	return checks, handshakes, roundTrips
+}
+
+// attempt connects to the endpoint, exchanges c.Message and
+// closes the connection. It returns how long the attempt took
+// up to closing the connection, and how long the handshake and
+// the message round trip took.
+func (c Checker) attempt(dialer *websocket.Dialer) (rtt, handshake, roundTrip time.Duration, err error) {
+	start := time.Now()
+	conn, resp, err := dialer.Dial(c.URL, c.Headers)
+	handshake = time.Since(start)
+	if err != nil {
+		if resp != nil {
+			return handshake, handshake, 0, fmt.Errorf("handshake failed with status %s", resp.Status)
+		}
+		return handshake, handshake, 0, err
+	}
+	defer conn.Close()
+
+	if len(c.Subprotocols) > 0 && conn.Subprotocol() == "" {
+		return time.Since(start), handshake, 0, errors.New("server did not accept any of the subprotocols")
+	}
+
+	if c.Message != "" {
+		sent := time.Now()
+		if err := conn.SetWriteDeadline(sent.Add(c.Timeout)); err != nil {
+			return time.Since(start), handshake, 0, err
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(c.Message)); err != nil {
+			return time.Since(start), handshake, 0, err
+		}
+		if err := conn.SetReadDeadline(sent.Add(c.Timeout)); err != nil {
+			return time.Since(start), handshake, 0, err
+		}
+		_, reply, err := conn.ReadMessage()
+		roundTrip = time.Since(sent)
+		if err != nil {
+			return time.Since(start), handshake, roundTrip, err
+		}
+		if err := c.checkDown(string(reply)); err != nil {
+			return time.Since(start), handshake, roundTrip, err
+		}
+	}
+
+	// closing may wait for the server, so it isn't timed
+	rtt = time.Since(start)
+	return rtt, handshake, roundTrip, c.close(conn)
+}
+
+// close closes conn cleanly: it sends a close message and
+// waits briefly for the server to reply with its own.
+func (c Checker) close(conn *websocket.Conn) error {
+	deadline := time.Now().Add(time.Second)
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if err := conn.WriteControl(websocket.CloseMessage, msg, deadline); err != nil {
+		return fmt.Errorf("closing connection: %w", err)
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return err
+	}
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			// the server has replied, or doesn't
+			// bother to; either is fine
+			return nil
+		}
+	}
+}
+
+// conclude takes the data in result from the attempts and
+// computes remaining values needed to fill out the result.
+// It detects degraded (high-latency) responses and makes
+// the conclusion about the result's status.
+func (c Checker) conclude(result types.Result) types.Result {
+	result.ThresholdRTT = c.ThresholdRTT
+
+	// Check errors (down)
+	for i := range result.Times {
+		if result.Times[i].Error != "" {
+			result.Notice = result.Times[i].Error
+			result.Down = true
+			return result
+		}
+	}
+
+	// Check round trip time (degraded)
+	if c.ThresholdRTT > 0 {
+		stats := result.ComputeStats()
+		if stats.Median > c.ThresholdRTT {
+			result.Notice = fmt.Sprintf("median round trip time exceeded threshold (%s)", c.ThresholdRTT)
+			result.Degraded = true
+			return result
+		}
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// checkDown checks whether the endpoint is down based on the
+// reply to c.Message. It returns a non-nil error if down.
+func (c Checker) checkDown(reply string) error {
+	if c.MustContain != "" && !strings.Contains(reply, c.MustContain) {
+		return fmt.Errorf("reply does not contain '%s'", c.MustContain)
+	}
+	if c.MustNotContain != "" && strings.Contains(reply, c.MustNotContain) {
+		return fmt.Errorf("reply contains '%s'", c.MustNotContain)
+	}
+	return nil
+}
+
+// metrics returns the median handshake and round trip times
+// as metrics, in seconds.
+func metrics(handshakes, roundTrips []time.Duration) []types.Metric {
+	var metrics []types.Metric
+	for _, m := range []struct {
+		label     string
+		durations []time.Duration
+	}{
+		{"handshake", handshakes},
+		{"round_trip", roundTrips},
+	} {
+		if len(m.durations) == 0 {
+			continue
+		}
+		attempts := make(types.Attempts, len(m.durations))
+		for i, d := range m.durations {
+			attempts[i].RTT = d
+		}
+		median := types.Result{Times: attempts}.ComputeStats().Median
+		metrics = append(metrics, types.Metric{Label: m.label, Value: median.Seconds(), Unit: "s"})
+	}
+	return metrics
+}
@@ -0,0 +1,118 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestChecker(t *testing.T) {
+	var closed int32
+	upgrader := websocket.Upgrader{Subprotocols: []string{"chat.v2"}}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			kind, msg, err := conn.ReadMessage()
+			if err != nil {
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+					atomic.AddInt32(&closed, 1)
+				}
+				return
+			}
+			if string(msg) == "hang" {
+				continue
+			}
+			conn.WriteMessage(kind, []byte("echo: "+string(msg)))
+		}
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	tlsSrv := httptest.NewTLSServer(handler)
+	defer tlsSrv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	wssURL := "wss" + strings.TrimPrefix(tlsSrv.URL, "https")
+	headers := http.Header{"Authorization": {"Bearer secret"}}
+
+	for i, test := range []struct {
+		checker Checker
+		status  string
+		notice  string
+		metrics int
+	}{
+		{Checker{URL: wsURL, Headers: headers}, "healthy", "", 1},
+		{Checker{URL: wsURL, Headers: headers, Message: "ping", MustContain: "echo: ping"}, "healthy", "", 2},
+		{Checker{URL: wsURL, Headers: headers, Message: "ping", MustNotContain: "ping"}, "down", "reply contains 'ping'", 0},
+		{Checker{URL: wsURL, Headers: headers, Message: "hang", Timeout: 200 * time.Millisecond}, "down", "read tcp", 0},
+		{Checker{URL: wsURL, Headers: headers, Subprotocols: []string{"chat.v1", "chat.v2"}}, "healthy", "", 1},
+		{Checker{URL: wsURL, Headers: headers, Subprotocols: []string{"chat.v1"}}, "down", "server did not accept", 0},
+		{Checker{URL: wsURL}, "down", "handshake failed with status 401", 0},
+		{Checker{URL: wssURL, Headers: headers, TLSSkipVerify: true, Message: "ping"}, "healthy", "", 2},
+		{Checker{URL: wssURL, Headers: headers}, "down", "tls: failed to verify certificate", 0},
+		{Checker{URL: wsURL, Headers: headers, ThresholdRTT: time.Nanosecond}, "degraded", "median round trip time", 1},
+	} {
+		tc := test.checker
+		tc.Name = "Test"
+		tc.Attempts = 2
+		result, err := tc.Check()
+		if err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+			continue
+		}
+		if got, want := string(result.Status()), test.status; got != want {
+			t.Errorf("Test %d: Expected status %s, got %s: %s", i, want, got, result.Notice)
+		}
+		if !strings.HasPrefix(result.Notice, test.notice) {
+			t.Errorf("Test %d: Expected notice '%s', got '%s'", i, test.notice, result.Notice)
+		}
+		if got, want := len(result.Metrics), test.metrics; got != want {
+			t.Errorf("Test %d: Expected %d metrics, got %v", i, want, result.Metrics)
+		}
+	}
+
+	// healthy attempts close the connection cleanly
+	time.Sleep(100 * time.Millisecond)
+	if atomic.LoadInt32(&closed) == 0 {
+		t.Error("Expected connections to be closed with a close message")
+	}
+
+	// a server that never replies to the close message
+	// doesn't count against the round trip time
+	done := make(chan struct{})
+	defer close(done)
+	silent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		<-done
+	}))
+	defer silent.Close()
+	tc := Checker{Name: "Test", URL: "ws" + strings.TrimPrefix(silent.URL, "http"), ThresholdRTT: 500 * time.Millisecond}
+	result, err := tc.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if !result.Healthy {
+		t.Errorf("Expected status healthy, got %s: %s", result.Status(), result.Notice)
+	}
+
+	tc = Checker{URL: srv.URL}
+	if _, err := tc.Check(); err == nil {
+		t.Error("Expected an error for an http URL, didn't get one")
+	}
+}
@@ -43,6 +43,50 @@ type Checkup struct {
 	// completed. Notifier may evaluate and choose to
 	// send a notification of potential problems.
 	Notifiers []Notifier `json:"notifiers,omitempty"`
+
+	// Dependencies maps the title of a check to the titles
+	// of the checks it depends on. If a check is unhealthy
+	// while one of its dependencies is down, its result is
+	// marked unreachable and no notification is sent for it.
+	Dependencies map[string][]string `json:"dependencies,omitempty"`
+
+	// Groups are named sets of checks whose aggregate
+	// status is added to the results, after the results
+	// of the individual checks.
+	Groups []Group `json:"groups,omitempty"`
+
+	// partial is set by WithTags: some checks may have been
+	// left out, so groups only cover the checks that remain.
+	partial bool
+}
+
+// WithTags returns a copy of c with only the checkers whose
+// tags include all of tags, so that only their checks are
+// performed, stored and notified. Checkers that aren't
+// Tagged are left out. Groups then only
+// aggregate the checks that remain, and are left out if none
+// do. If tags is empty, c is returned as is.
+func (c Checkup) WithTags(tags map[string]string) Checkup {
+	if len(tags) == 0 {
+		return c
+	}
+	checkers := []Checker{}
+	for _, checker := range c.Checkers {
+		if (types.Result{Tags: checkerTags(checker)}).HasTags(tags) {
+			checkers = append(checkers, checker)
+		}
+	}
+	c.Checkers = checkers
+	c.partial = true
+	return c
+}
+
+// checkerTags returns the tags of checker, if it is Tagged.
+func checkerTags(checker Checker) map[string]string {
+	if tagged, ok := checker.(Tagged); ok {
+		return tagged.ResultTags()
+	}
+	return nil
 }
 
 // Check performs the health checks. An error is only
@@ -73,6 +117,19 @@ func (c Checkup) Check() ([]types.Result, error) {
 	}
 	wg.Wait()
 
+	markUnreachable(results, c.Dependencies)
+
+	for _, group := range c.Groups {
+		result, ok, err := group.conclude(results, c.partial)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			results = append(results, result)
+		}
+	}
+
 	if !c.Timestamp.IsZero() {
 		for i := range results {
 			results[i].Timestamp = c.Timestamp.UTC().UnixNano()
@@ -83,8 +140,17 @@ func (c Checkup) Check() ([]types.Result, error) {
 		return results, errs
 	}
 
+	// aggregate results repeat their members, and unreachable
+	// results are explained by their dependencies
+	notify := make([]types.Result, 0, len(results))
+	for _, result := range results {
+		if !result.Unreachable && result.Members == nil {
+			notify = append(notify, result)
+		}
+	}
+
 	for _, service := range c.Notifiers {
-		err := service.Notify(results)
+		err := service.Notify(notify)
 		if err != nil {
 			log.Printf("ERROR sending notifications for %s: %s", service.Type(), err)
 		}
@@ -147,11 +213,15 @@ func (c Checkup) MarshalJSON() ([]byte, error) {
 	// Start with the fields of c that don't require special
 	// handling; unfortunately this has to mimic c's definition.
 	easy := struct {
-		ConcurrentChecks int       `json:"concurrent_checks,omitempty"`
-		Timestamp        time.Time `json:"timestamp,omitempty"`
+		ConcurrentChecks int                 `json:"concurrent_checks,omitempty"`
+		Timestamp        time.Time           `json:"timestamp,omitempty"`
+		Dependencies     map[string][]string `json:"dependencies,omitempty"`
+		Groups           []Group             `json:"groups,omitempty"`
 	}{
 		ConcurrentChecks: c.ConcurrentChecks,
 		Timestamp:        c.Timestamp,
+		Dependencies:     c.Dependencies,
+		Groups:           c.Groups,
 	}
 	result, err := json.Marshal(easy)
 	if err != nil {
@@ -298,6 +368,27 @@ func (c *Checkup) UnmarshalJSON(b []byte) error {
 		}
 		c.Notifiers = append(c.Notifiers, notifier)
 	}
+	return c.validateGroups()
+}
+
+// validateGroups checks that the groups of c have known
+// policies and only name the checks of c. The checks can only
+// be validated if every checker is Titled.
+func (c Checkup) validateGroups() error {
+	titles := make(map[string]bool, len(c.Checkers))
+	for _, checker := range c.Checkers {
+		titled, ok := checker.(Titled)
+		if !ok {
+			titles = nil
+			break
+		}
+		titles[titled.Title()] = true
+	}
+	for _, group := range c.Groups {
+		if err := group.validate(titles); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
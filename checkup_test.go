@@ -3,7 +3,9 @@ package checkup
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -81,6 +83,199 @@ func TestCheckAndStoreEvery(t *testing.T) {
 	}
 }
 
+func TestDependenciesAndGroups(t *testing.T) {
+	f := new(fake)
+	c := Checkup{
+		Checkers: []Checker{
+			static{Title: "lb", Down: true},
+			static{Title: "db", Healthy: true},
+			static{Title: "api", Down: true},
+			static{Title: "web", Degraded: true},
+			static{Title: "cdn", Healthy: true},
+		},
+		Dependencies: map[string][]string{
+			"api": {"lb", "db"},
+			"web": {"db"},
+			"cdn": {"lb"},
+		},
+		Groups: []Group{
+			{Name: "Backend", Checks: []string{"lb", "db", "api"}},
+			{Name: "Frontend", Checks: []string{"web", "cdn"}},
+			{Name: "Majority", Checks: []string{"lb", "db", "api", "cdn"}, Policy: PolicyMajority},
+			{Name: "All", Checks: []string{"lb", "api"}, Policy: PolicyAllDown},
+		},
+		Notifiers: []Notifier{f},
+	}
+
+	results, err := c.Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := len(results), 9; got != want {
+		t.Fatalf("Expected %d results, got %d", want, got)
+	}
+
+	for i, test := range []struct {
+		unreachable bool
+		group       string
+	}{
+		{false, "Backend"},
+		{false, "Backend"},
+		{true, "Backend"},
+		{false, "Frontend"},
+		{false, "Frontend"},
+	} {
+		if got := results[i].Unreachable; got != test.unreachable {
+			t.Errorf("Expected %s Unreachable=%v, got %v", results[i].Title, test.unreachable, got)
+		}
+		if got := results[i].Group; got != test.group {
+			t.Errorf("Expected %s Group='%s', got '%s'", results[i].Title, test.group, got)
+		}
+	}
+	if got, want := results[2].Notice, "unreachable due to upstream lb"; got != want {
+		t.Errorf("Expected notice '%s', got '%s'", want, got)
+	}
+
+	for i, want := range []types.StatusText{
+		types.StatusDown,
+		types.StatusDegraded,
+		types.StatusDegraded,
+		types.StatusDown,
+	} {
+		result := results[len(c.Checkers)+i]
+		if got := result.Status(); got != want {
+			t.Errorf("Expected group %s to be %s, got %s", result.Title, want, got)
+		}
+	}
+	if got, want := results[5].Notice, "2 of 3 checks down"; got != want {
+		t.Errorf("Expected notice '%s', got '%s'", want, got)
+	}
+
+	if got, want := len(f.notifiedResults), 4; got != want {
+		t.Errorf("Expected %d results to be notified, got %d", want, got)
+	}
+	for _, result := range f.notifiedResults {
+		if result.Title == "api" {
+			t.Error("Expected unreachable result not to be notified")
+		}
+	}
+
+	c.Groups = []Group{{Name: "Bad", Policy: "bogus"}}
+	results, err = c.Check()
+	if err == nil {
+		t.Error("Expected an error with an unknown group policy, didn't get one")
+	}
+	if got, want := len(results), len(c.Checkers); got != want {
+		t.Errorf("Expected %d results along with the error, got %d", want, got)
+	}
+
+	// a check without a result, such as one whose checker
+	// failed, is down without hiding the checker's error
+	f.returnErr = true
+	c.Checkers = []Checker{static{Title: "db", Healthy: true}, f}
+	c.Groups = []Group{{Name: "Backend", Checks: []string{"db", "fake"}}}
+	results, err = c.Check()
+	if err == nil || !strings.Contains(err.Error(), errTest.Error()) {
+		t.Errorf("Expected the checker's error, got %v", err)
+	}
+	if got, want := len(results), 3; got != want {
+		t.Fatalf("Expected %d results, got %d", want, got)
+	}
+	if group := results[2]; !group.Down || group.Notice != "1 of 2 checks down" {
+		t.Errorf("Expected group to be down with 1 of 2 checks down, got %s: %s", group.Status(), group.Notice)
+	}
+}
+
+func TestGroupValidation(t *testing.T) {
+	config := `{"checkers": [{"type": "tcp", "endpoint_name": "db", "endpoint_url": "localhost:5432"}], "groups": [%s]}`
+	for i, test := range []struct {
+		group string
+		valid bool
+	}{
+		{`{"name": "Backend", "checks": ["db"]}`, true},
+		{`{"name": "Backend", "checks": ["db"], "policy": "majority"}`, true},
+		{`{"name": "Backend", "checks": ["db", "missing"]}`, false},
+		{`{"name": "Backend", "checks": ["db"], "policy": "bogus"}`, false},
+	} {
+		var c Checkup
+		err := c.UnmarshalJSON([]byte(fmt.Sprintf(config, test.group)))
+		if test.valid && err != nil {
+			t.Errorf("Test %d: Didn't expect an error: %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("Test %d: Expected an error for %s, didn't get one", i, test.group)
+		}
+	}
+}
+
+func TestWithTags(t *testing.T) {
+	f := new(fake)
+	c := Checkup{
+		Checkers: []Checker{
+			static{Title: "web-prod", Healthy: true, Tags: map[string]string{"env": "prod", "team": "web"}},
+			static{Title: "web-staging", Down: true, Tags: map[string]string{"env": "staging", "team": "web"}},
+			static{Title: "db-prod", Degraded: true, Tags: map[string]string{"env": "prod"}},
+			static{Title: "untagged", Down: true},
+		},
+		Groups: []Group{
+			{Name: "Web", Checks: []string{"web-prod", "web-staging"}},
+			{Name: "Staging", Checks: []string{"web-staging"}},
+		},
+		Notifiers: []Notifier{f},
+	}
+
+	results, err := c.WithTags(map[string]string{"env": "prod"}).Check()
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	var titles []string
+	for _, result := range results {
+		titles = append(titles, result.Title)
+	}
+	if got, want := strings.Join(titles, ","), "web-prod,db-prod,Web"; got != want {
+		t.Fatalf("Expected results %s, got %s", want, got)
+	}
+	if got, want := strings.Join(results[2].Members, ","), "web-prod"; got != want {
+		t.Errorf("Expected group members %s, got %s", want, got)
+	}
+	if !results[2].Healthy {
+		t.Errorf("Expected group to be healthy, got %s", results[2].Status())
+	}
+	if got, want := len(f.notifiedResults), 2; got != want {
+		t.Errorf("Expected %d results to be notified, got %d", want, got)
+	}
+
+	if got, want := len(c.WithTags(nil).Checkers), 4; got != want {
+		t.Errorf("Expected %d checkers without tags, got %d", want, got)
+	}
+	if got, want := len(c.WithTags(map[string]string{"env": "prod", "team": "web"}).Checkers), 1; got != want {
+		t.Errorf("Expected %d checkers, got %d", want, got)
+	}
+}
+
+func TestCheckersAreTagged(t *testing.T) {
+	for _, typeName := range []string{
+		"certfile", "disk", "dns", "docker", "exec", "file", "grpc",
+		"heartbeat", "http", "imap", "kubernetes", "load", "memory",
+		"mysql", "ntp", "pop3", "postgres", "process", "redis",
+		"scenario", "smtp", "ssh", "tcp", "tls", "websocket",
+	} {
+		checker, err := checkerDecode(typeName, []byte(`{"tags": {"env": "prod"}}`))
+		if err != nil {
+			t.Errorf("%s: Didn't expect an error: %v", typeName, err)
+			continue
+		}
+		tagged, ok := checker.(Tagged)
+		if !ok {
+			t.Errorf("%s: Expected checker to be Tagged", typeName)
+			continue
+		}
+		if got := tagged.ResultTags()["env"]; got != "prod" {
+			t.Errorf("%s: Expected tag env=prod, got '%s'", typeName, got)
+		}
+	}
+}
+
 func TestComputeStats(t *testing.T) {
 	s := types.Result{Times: []types.Attempt{
 		{RTT: 7 * time.Second},
@@ -212,6 +407,8 @@ type fake struct {
 	stored     []types.Result
 	maintained int
 	notified   int
+
+	notifiedResults []types.Result
 }
 
 func (f *fake) Type() string {
@@ -254,5 +451,21 @@ func (f *fake) Notify(results []types.Result) error {
 	defer f.Unlock()
 
 	f.notified++
+	f.notifiedResults = results
 	return nil
 }
+
+// static is a Checker that always returns itself as the result.
+type static types.Result
+
+func (s static) Type() string {
+	return "static"
+}
+
+func (s static) Check() (types.Result, error) {
+	return types.Result(s), nil
+}
+
+func (s static) ResultTags() map[string]string {
+	return s.Tags
+}
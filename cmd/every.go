@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/sourcegraph/checkup/types"
 )
 
 var everyCmd = &cobra.Command{
@@ -31,7 +33,10 @@ Examples:
 
   $ checkup every 10m
   $ checkup every day
-  $ checkup every 1h30m`,
+  $ checkup every 1h30m
+  $ checkup every 5m --tag env=prod
+
+Use --tag to only perform checks with certain tags.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) != 1 {
 			fmt.Println(cmd.Long)
@@ -57,7 +62,12 @@ Examples:
 			log.Fatal(err)
 		}
 
-		c := loadCheckup()
+		tags, err := types.ParseTags(tagFilter)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		c := loadCheckup().WithTags(tags)
 		if len(c.Checkers) == 0 {
 			log.Fatal("no checkers configured")
 		}
@@ -72,4 +82,5 @@ Examples:
 
 func init() {
 	RootCmd.AddCommand(everyCmd)
+	everyCmd.Flags().StringArrayVar(&tagFilter, "tag", nil, "Only perform checks with this tag (key=value); may be repeated")
 }
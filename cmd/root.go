@@ -8,11 +8,13 @@ import (
 	"os"
 
 	"github.com/sourcegraph/checkup"
+	"github.com/sourcegraph/checkup/types"
 	"github.com/spf13/cobra"
 )
 
 var configFile string
 var storeResults bool
+var tagFilter []string
 
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
@@ -28,18 +30,27 @@ You can specify a different file location using the
 
 Running checkup without any arguments will invoke
 a single checkup and print results to stdout. To
-store the results of the check, use --store.`,
+store the results of the check, use --store.
+
+To only perform checks with certain tags, use --tag
+one or more times, e.g. --tag env=prod.`,
 
 	Run: func(cmd *cobra.Command, args []string) {
 		allHealthy := true
 		c := loadCheckup()
 
+		tags, err := types.ParseTags(tagFilter)
+		if err != nil {
+			log.Fatal(err)
+		}
+
 		if storeResults {
 			if c.Storage == nil {
 				log.Fatal("no storage configured")
 			}
 		}
 
+		c = c.WithTags(tags)
 		results, err := c.Check()
 		if err != nil {
 			log.Fatal(err)
@@ -93,4 +104,5 @@ func Execute() {
 func init() {
 	RootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "checkup.json", "JSON config file")
 	RootCmd.Flags().BoolVar(&storeResults, "store", false, "Store results")
+	RootCmd.Flags().StringArrayVar(&tagFilter, "tag", nil, "Only perform checks with this tag (key=value); may be repeated")
 }
@@ -10,7 +10,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/sourcegraph/checkup"
+	"github.com/sourcegraph/checkup/check/heartbeat"
 	"github.com/sourcegraph/checkup/storage/fs"
+	"github.com/sourcegraph/checkup/types"
 )
 
 var listenAddr string
@@ -23,12 +25,21 @@ files from the configured storage provider. The intended use is to
 provide a web server that will read any stored checks from storages like
 fs, mysql, postgresql, sqlite3....
 
+Check files can be filtered by tag with one or more tag query
+parameters, e.g. /1588888888-check.json?tag=env=prod.
+
+Jobs monitored by heartbeat checkers report to /ping/{token} when
+they succeed, and to /ping/{token}/start and /ping/{token}/fail when
+they start and fail. Heartbeats are saved to the state_file of their
+checker.
+
 By default, checkup.json configuration file will be loaded and used.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var prov checkup.StorageReader
 		var err error
 
-		prov, err = storageReaderConfig()
+		c := loadCheckup()
+		prov, err = storageReaderConfig(c)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -42,6 +53,9 @@ By default, checkup.json configuration file will be loaded and used.`,
 			mux.Handle("/"+folder+"/", statuspage)
 		}
 		mux.HandleFunc("/", serveHandler(prov))
+		if stores := heartbeatStores(c); len(stores) > 0 {
+			mux.Handle("/ping/", heartbeat.Handler(stores))
+		}
 
 		if err := http.ListenAndServe(listenAddr, mux); err != nil {
 			log.Fatal(err)
@@ -74,20 +88,39 @@ func serveHandler(reader checkup.StorageReader) http.HandlerFunc {
 			return
 		}
 		if _, ok := index[requestedFile]; ok {
+			tags, err := types.ParseTags(r.URL.Query()["tag"])
+			if err != nil {
+				writeError(w, err)
+				return
+			}
 			file, err := reader.Fetch(requestedFile)
 			if err != nil {
 				writeError(w, err)
 				return
 			}
-			json.NewEncoder(w).Encode(file)
+			json.NewEncoder(w).Encode(filterResults(file, tags))
 			return
 		}
 		writeError(w, fmt.Errorf("file not found: %s", requestedFile))
 	}
 }
 
-func storageReaderConfig() (checkup.StorageReader, error) {
-	c := loadCheckup()
+// filterResults returns the results that have all of the
+// given tags.
+func filterResults(results []types.Result, tags map[string]string) []types.Result {
+	if len(tags) == 0 {
+		return results
+	}
+	filtered := []types.Result{}
+	for _, result := range results {
+		if result.HasTags(tags) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+func storageReaderConfig(c checkup.Checkup) (checkup.StorageReader, error) {
 	if c.Storage == nil {
 		return nil, fmt.Errorf("no storage configuration found")
 	}
@@ -98,6 +131,26 @@ func storageReaderConfig() (checkup.StorageReader, error) {
 	return prov, nil
 }
 
+// heartbeatStores returns the stores of the heartbeat checkers
+// of c, by token. Checkers sharing a state file share a store.
+func heartbeatStores(c checkup.Checkup) map[string]*heartbeat.Store {
+	stores := map[string]*heartbeat.Store{}
+	byFile := map[string]*heartbeat.Store{}
+	for _, checker := range c.Checkers {
+		hc, ok := checker.(heartbeat.Checker)
+		if !ok {
+			continue
+		}
+		store := hc.Store()
+		if shared, ok := byFile[store.File]; ok {
+			store = shared
+		}
+		byFile[store.File] = store
+		stores[hc.Token] = store
+	}
+	return stores
+}
+
 func init() {
 	RootCmd.AddCommand(serveCmd)
 	serveCmd.Flags().StringVarP(&listenAddr, "listen", "", ":3000", "The listen address for the HTTP server")
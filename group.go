@@ -0,0 +1,180 @@
+package checkup
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Policies for computing the aggregate status of a Group.
+const (
+	// PolicyAnyDown gives a group the worst status of
+	// any of its checks. This is the default.
+	PolicyAnyDown = "any-down"
+
+	// PolicyMajority marks a group down only if more
+	// than half of its checks are down, and degraded if
+	// any of its checks are unhealthy.
+	PolicyMajority = "majority"
+
+	// PolicyAllDown marks a group down only if all of
+	// its checks are down, and degraded if any of its
+	// checks are unhealthy.
+	PolicyAllDown = "all-down"
+)
+
+// Group is a named set of checks, such as the checks that
+// make up one component of a service, whose aggregate
+// status is reported as a result of its own.
+type Group struct {
+	// Name is the name of the group; it is the title of
+	// the aggregate result.
+	Name string `json:"name"`
+
+	// Checks are the titles of the checks in the group.
+	Checks []string `json:"checks"`
+
+	// Policy is how the aggregate status is computed:
+	// PolicyAnyDown (default), PolicyMajority or
+	// PolicyAllDown.
+	Policy string `json:"policy,omitempty"`
+}
+
+// validate returns an error if the policy of g is unknown,
+// or if g names a check that isn't in titles. If titles is
+// nil, the checks aren't validated.
+func (g Group) validate(titles map[string]bool) error {
+	switch g.Policy {
+	case "", PolicyAnyDown, PolicyMajority, PolicyAllDown:
+	default:
+		return fmt.Errorf("unknown policy for group %s: %s", g.Name, g.Policy)
+	}
+	if titles == nil {
+		return nil
+	}
+	for _, title := range g.Checks {
+		if !titles[title] {
+			return fmt.Errorf("group %s: no check titled %s", g.Name, title)
+		}
+	}
+	return nil
+}
+
+// conclude computes the aggregate result of g from results,
+// and tags the member results that aren't yet part of a group
+// with the name of g. A check of g without a result, such as
+// one whose checker failed, counts as down. If partial is set,
+// results may lack some of the checks of g, which are left
+// out, and ok is false if they lack all.
+func (g Group) conclude(results []types.Result, partial bool) (result types.Result, ok bool, err error) {
+	result = types.NewResult()
+	result.Title = g.Name
+	if err := g.validate(nil); err != nil {
+		return result, false, err
+	}
+
+	members := make(map[string]bool, len(g.Checks))
+	for _, title := range g.Checks {
+		members[title] = true
+	}
+
+	var total, down, unhealthy int
+	worst := types.StatusUnknown
+	found := make(map[string]bool, len(g.Checks))
+	count := func(status types.StatusText) {
+		if status.PriorityOver(worst) {
+			worst = status
+		}
+		total++
+		if status == types.StatusDown {
+			down++
+		}
+		if status != types.StatusHealthy {
+			unhealthy++
+		}
+	}
+	for i := range results {
+		if !members[results[i].Title] {
+			continue
+		}
+		found[results[i].Title] = true
+		if results[i].Group == "" {
+			results[i].Group = g.Name
+		}
+		count(results[i].Status())
+	}
+
+	if partial && total == 0 {
+		return result, false, nil
+	}
+	result.Members = []string{}
+	for _, title := range g.Checks {
+		if found[title] {
+			result.Members = append(result.Members, title)
+		} else if !partial {
+			result.Members = append(result.Members, title)
+			count(types.StatusDown)
+		}
+	}
+
+	status := worst
+	switch g.Policy {
+	case PolicyMajority:
+		if worst == types.StatusDown && down*2 <= total {
+			status = types.StatusDegraded
+		}
+	case PolicyAllDown:
+		if worst == types.StatusDown && down < total {
+			status = types.StatusDegraded
+		}
+	}
+
+	switch status {
+	case types.StatusHealthy:
+		result.Healthy = true
+	case types.StatusDegraded:
+		result.Degraded = true
+	case types.StatusDown:
+		result.Down = true
+	}
+	if down > 0 {
+		result.Notice = fmt.Sprintf("%d of %d checks down", down, total)
+	} else if unhealthy > 0 {
+		result.Notice = fmt.Sprintf("%d of %d checks unhealthy", unhealthy, total)
+	}
+	return result, true, nil
+}
+
+// markUnreachable marks the unhealthy results that depend on
+// a check that is down as unreachable. dependencies maps the
+// title of a check to the titles of the checks it depends on.
+func markUnreachable(results []types.Result, dependencies map[string][]string) {
+	down := make(map[string]bool, len(results))
+	for _, result := range results {
+		if result.Down {
+			down[result.Title] = true
+		}
+	}
+
+	for i := range results {
+		if results[i].Healthy {
+			continue
+		}
+		var upstream []string
+		for _, title := range dependencies[results[i].Title] {
+			if down[title] {
+				upstream = append(upstream, title)
+			}
+		}
+		if len(upstream) == 0 {
+			continue
+		}
+		results[i].Unreachable = true
+		notice := "unreachable due to upstream " + strings.Join(upstream, ", ")
+		if results[i].Notice != "" {
+			notice += "; " + results[i].Notice
+		}
+		results[i].Notice = notice
+	}
+}
@@ -10,6 +10,20 @@ type Checker interface {
 	Check() (types.Result, error)
 }
 
+// Titled is a Checker that knows the title of its result
+// before it checks, so that the checks named by groups can be
+// validated when the configuration is loaded.
+type Titled interface {
+	Title() string
+}
+
+// Tagged is a Checker whose results carry tags: labels, such
+// as team, environment or service, by which results can be
+// filtered and grouped.
+type Tagged interface {
+	ResultTags() map[string]string
+}
+
 // Storage can store results.
 type Storage interface {
 	Type() string
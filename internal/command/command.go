@@ -0,0 +1,119 @@
+// Package command runs external programs for checkers and
+// notifiers, so that neither a program nor the processes it
+// starts can hold them up past its timeout.
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// GracePeriod is how long Run keeps reading the output of a
+// program after it exits. Descendants that escaped its process
+// group may hold the output open indefinitely.
+const GracePeriod = time.Second
+
+// Run runs cmd, copying its standard output and standard error
+// to stdout and stderr, which must be safe for concurrent use
+// if they share a writer. If cmd doesn't finish within timeout,
+// its process group is killed and an error is returned.
+func Run(cmd *exec.Cmd, stdout, stderr io.Writer, timeout time.Duration) error {
+	// The output is read from pipes of our own rather than
+	// by exec, whose Wait would block until every process
+	// holding them open exits.
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		stdoutR.Close()
+		stdoutW.Close()
+		return err
+	}
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
+	setProcessGroup(cmd)
+
+	err = cmd.Start()
+	stdoutW.Close()
+	stderrW.Close()
+	if err != nil {
+		stdoutR.Close()
+		stderrR.Close()
+		return err
+	}
+
+	var copying sync.WaitGroup
+	copying.Add(2)
+	go func() {
+		defer copying.Done()
+		_, _ = io.Copy(stdout, stdoutR)
+	}()
+	go func() {
+		defer copying.Done()
+		_, _ = io.Copy(stderr, stderrR)
+	}()
+	copied := make(chan struct{})
+	go func() {
+		copying.Wait()
+		close(copied)
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	timer := time.NewTimer(timeout)
+	select {
+	case err = <-done:
+		timer.Stop()
+	case <-timer.C:
+		killProcessGroup(cmd)
+		<-done
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	select {
+	case <-copied:
+	case <-time.After(GracePeriod):
+		// closing the pipes interrupts the reads
+		stdoutR.Close()
+		stderrR.Close()
+		<-copied
+	}
+	stdoutR.Close()
+	stderrR.Close()
+	return err
+}
+
+// Buffer is a buffer, safe for concurrent use, that discards
+// writes beyond Max bytes.
+type Buffer struct {
+	Max int
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.Max - b.buf.Len(); room < len(p) {
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// Bytes returns the contents of b.
+func (b *Buffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
+}
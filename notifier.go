@@ -4,11 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/sourcegraph/checkup/notifier/digest"
 	"github.com/sourcegraph/checkup/notifier/discord"
+	"github.com/sourcegraph/checkup/notifier/exec"
+	"github.com/sourcegraph/checkup/notifier/log"
 	"github.com/sourcegraph/checkup/notifier/mail"
 	"github.com/sourcegraph/checkup/notifier/mailgun"
 	"github.com/sourcegraph/checkup/notifier/pushover"
 	"github.com/sourcegraph/checkup/notifier/slack"
+	"github.com/sourcegraph/checkup/notifier/syslog"
 )
 
 func notifierDecode(typeName string, config json.RawMessage) (Notifier, error) {
@@ -23,6 +27,16 @@ func notifierDecode(typeName string, config json.RawMessage) (Notifier, error) {
 		return pushover.New(config)
 	case discord.Type:
 		return discord.New(config)
+	case syslog.Type:
+		return syslog.New(config)
+	case log.Type:
+		return log.New(config)
+	case exec.Type:
+		return exec.New(config)
+	case digest.Type:
+		return digest.New(config, func(typeName string, config json.RawMessage) (digest.Sender, error) {
+			return notifierDecode(typeName, config)
+		})
 	default:
 		return nil, fmt.Errorf(errUnknownNotifierType, typeName)
 	}
@@ -0,0 +1,299 @@
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "digest"
+
+// Sender is the notifier that digests are delivered through.
+// Any checkup.Notifier satisfies it.
+type Sender interface {
+	Type() string
+	Notify([]types.Result) error
+}
+
+// DecodeFunc decodes the configuration of the wrapped notifier.
+type DecodeFunc func(typeName string, config json.RawMessage) (Sender, error)
+
+// Notifier sits in front of another notifier and batches
+// issues into a single digest message, so that a failing
+// shared dependency doesn't produce one message per check
+// on every round.
+//
+// A check is reported once when it becomes unhealthy or
+// changes status. Checks that stay unhealthy are not
+// reported again; instead, a summary of them is sent every
+// SummaryInterval. Recoveries clear the check's state.
+type Notifier struct {
+	// Window is how long new issues are collected before
+	// they are sent as one digest. The window is evaluated
+	// on each call to Notify, so it is effectively rounded
+	// up to the check interval. If zero, a digest is sent
+	// at the end of every round that has new issues.
+	Window time.Duration `json:"window,omitempty"`
+
+	// RateInterval is how often a token is added to the
+	// rate limiting bucket; every message sent through
+	// Sender costs one token. If zero, messages are not
+	// rate limited.
+	RateInterval time.Duration `json:"rate_interval,omitempty"`
+
+	// Burst is the capacity of the rate limiting bucket.
+	// Default is 1.
+	Burst int `json:"burst,omitempty"`
+
+	// SummaryInterval is how often to send a summary of
+	// checks that remain unhealthy (e.g. "12 still down")
+	// in place of repeated alerts. If zero, no summaries
+	// are sent.
+	SummaryInterval time.Duration `json:"summary_interval,omitempty"`
+
+	// Sender is the wrapped notifier. It is configured
+	// under the "notifier" key, with its own "type".
+	Sender Sender `json:"-"`
+
+	mu          sync.Mutex
+	failing     map[string]types.Result
+	pending     map[string]types.Result
+	windowStart time.Time
+	lastSent    time.Time
+	bucket      bucket
+
+	// now returns the current time; overridden in tests.
+	now func() time.Time
+}
+
+// New creates a new Notifier instance based on json config.
+// The wrapped notifier is decoded with decode.
+func New(config json.RawMessage, decode DecodeFunc) (*Notifier, error) {
+	notifier := new(Notifier)
+	if err := json.Unmarshal(config, notifier); err != nil {
+		return notifier, err
+	}
+
+	raw := struct {
+		Notifier json.RawMessage `json:"notifier"`
+	}{}
+	if err := json.Unmarshal(config, &raw); err != nil {
+		return notifier, err
+	}
+	if raw.Notifier == nil {
+		return notifier, fmt.Errorf("digest: no notifier configured")
+	}
+	inner := struct {
+		Type string `json:"type"`
+	}{}
+	if err := json.Unmarshal(raw.Notifier, &inner); err != nil {
+		return notifier, err
+	}
+	sender, err := decode(inner.Type, raw.Notifier)
+	if err != nil {
+		return notifier, err
+	}
+	notifier.Sender = sender
+	return notifier, nil
+}
+
+// Type returns the notifier package name
+func (*Notifier) Type() string {
+	return Type
+}
+
+// MarshalJSON marshals n into JSON, including the wrapped
+// notifier with its type information.
+func (n *Notifier) MarshalJSON() ([]byte, error) {
+	type digest Notifier
+	b, err := json.Marshal((*digest)(n))
+	if err != nil || n.Sender == nil {
+		return b, err
+	}
+	sb, err := json.Marshal(n.Sender)
+	if err != nil {
+		return nil, err
+	}
+	typ := fmt.Sprintf(`{"type":"%s"`, n.Sender.Type())
+	if len(sb) > 2 {
+		typ += ","
+	}
+	sb = append([]byte(typ), sb[1:]...)
+	if len(b) > 2 {
+		b = append(b[:len(b)-1], ',')
+	} else {
+		b = b[:len(b)-1]
+	}
+	b = append(b, `"notifier":`...)
+	b = append(b, sb...)
+	return append(b, '}'), nil
+}
+
+// Notify implements notifier interface
+func (n *Notifier) Notify(results []types.Result) error {
+	if n.Sender == nil {
+		return fmt.Errorf("digest: no notifier configured")
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	if n.now != nil {
+		now = n.now()
+	}
+	if n.failing == nil {
+		n.failing = make(map[string]types.Result)
+		n.pending = make(map[string]types.Result)
+		n.bucket = bucket{interval: n.RateInterval, burst: n.Burst}
+	}
+
+	for _, result := range results {
+		key := result.Title + "\x00" + result.Endpoint
+		if result.Healthy {
+			delete(n.failing, key)
+			delete(n.pending, key)
+			continue
+		}
+		prev, ok := n.failing[key]
+		n.failing[key] = result
+		if !ok || prev.Status() != result.Status() {
+			n.pending[key] = result
+		}
+	}
+
+	if len(n.pending) > 0 {
+		if n.windowStart.IsZero() {
+			n.windowStart = now
+		}
+		if now.Sub(n.windowStart) < n.Window || !n.bucket.take(now) {
+			return nil
+		}
+		issues := sorted(n.pending)
+		n.pending = make(map[string]types.Result)
+		n.windowStart = time.Time{}
+		n.lastSent = now
+		return n.Sender.Notify([]types.Result{renderDigest(issues)})
+	}
+
+	if n.SummaryInterval > 0 && len(n.failing) > 0 &&
+		now.Sub(n.lastSent) >= n.SummaryInterval && n.bucket.take(now) {
+		n.lastSent = now
+		return n.Sender.Notify([]types.Result{renderSummary(sorted(n.failing))})
+	}
+
+	return nil
+}
+
+// sorted returns the results in m ordered by title.
+func sorted(m map[string]types.Result) []types.Result {
+	results := make([]types.Result, 0, len(m))
+	for _, result := range m {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Title == results[j].Title {
+			return results[i].Endpoint < results[j].Endpoint
+		}
+		return results[i].Title < results[j].Title
+	})
+	return results
+}
+
+// renderDigest combines issues into a single result that
+// carries the worst status among them.
+func renderDigest(issues []types.Result) types.Result {
+	result := combine(issues)
+	result.Title = "Checkup: " + countStatuses(issues, "%d %s")
+	return result
+}
+
+// renderSummary combines the checks that are still failing
+// into a single result.
+func renderSummary(failing []types.Result) types.Result {
+	result := combine(failing)
+	result.Title = "Checkup: " + countStatuses(failing, "%d still %s")
+	return result
+}
+
+func combine(results []types.Result) types.Result {
+	combined := types.NewResult()
+	status := types.StatusUnknown
+	var titles, lines []string
+	for _, result := range results {
+		if result.Status().PriorityOver(status) {
+			status = result.Status()
+		}
+		titles = append(titles, result.Title)
+		line := fmt.Sprintf("%s (%s): %s", result.Title, result.Endpoint, result.Status())
+		if result.Notice != "" {
+			line += " - " + result.Notice
+		}
+		lines = append(lines, line)
+	}
+	combined.Endpoint = strings.Join(titles, ", ")
+	combined.Notice = strings.Join(lines, "\n")
+	switch status {
+	case types.StatusDown:
+		combined.Down = true
+	case types.StatusDegraded:
+		combined.Degraded = true
+	}
+	return combined
+}
+
+// countStatuses renders the number of results of each
+// status with format, e.g. "3 down, 1 degraded".
+func countStatuses(results []types.Result, format string) string {
+	counts := make(map[types.StatusText]int)
+	for _, result := range results {
+		counts[result.Status()]++
+	}
+	var parts []string
+	for _, status := range []types.StatusText{types.StatusDown, types.StatusDegraded, types.StatusUnknown} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf(format, counts[status], status))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// bucket is a token bucket that holds at most burst tokens
+// and gains one token every interval.
+type bucket struct {
+	interval time.Duration
+	burst    int
+	tokens   float64
+	last     time.Time
+}
+
+// take reports whether a token was available at now, and
+// consumes it if so.
+func (b *bucket) take(now time.Time) bool {
+	if b.interval <= 0 {
+		return true
+	}
+	if b.burst < 1 {
+		b.burst = 1
+	}
+	if b.last.IsZero() {
+		b.tokens = float64(b.burst)
+	} else {
+		b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
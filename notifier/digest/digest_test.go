@@ -0,0 +1,125 @@
+package digest
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+type fakeSender struct {
+	sent []types.Result
+}
+
+func (f *fakeSender) Type() string {
+	return "fake"
+}
+
+func (f *fakeSender) Notify(results []types.Result) error {
+	f.sent = append(f.sent, results...)
+	return nil
+}
+
+func TestNotifier(t *testing.T) {
+	clock := time.Unix(0, 0)
+	sender := new(fakeSender)
+	n := &Notifier{
+		Window:          time.Minute,
+		RateInterval:    10 * time.Minute,
+		SummaryInterval: time.Hour,
+		Sender:          sender,
+		now:             func() time.Time { return clock },
+	}
+	round := func(d time.Duration, results ...types.Result) {
+		clock = clock.Add(d)
+		if err := n.Notify(results); err != nil {
+			t.Fatalf("Didn't expect an error: %v", err)
+		}
+	}
+	down := func(title string) types.Result {
+		return types.Result{Title: title, Endpoint: title + ".example.com", Down: true}
+	}
+	up := func(title string) types.Result {
+		return types.Result{Title: title, Endpoint: title + ".example.com", Healthy: true}
+	}
+
+	// issues within the window are batched
+	round(0, down("a"), down("b"), up("c"))
+	if got, want := len(sender.sent), 0; got != want {
+		t.Fatalf("Expected %d messages within window, got %d", want, got)
+	}
+	round(time.Minute, down("a"), down("b"), down("c"))
+	if got, want := len(sender.sent), 1; got != want {
+		t.Fatalf("Expected %d message after window, got %d", want, got)
+	}
+	digest := sender.sent[0]
+	if got, want := digest.Title, "Checkup: 3 down"; got != want {
+		t.Errorf("Expected digest title '%s', got '%s'", want, got)
+	}
+	if !digest.Down {
+		t.Errorf("Expected digest to be down, got %s", digest.Status())
+	}
+	if got, want := strings.Count(digest.Notice, "\n"), 2; got != want {
+		t.Errorf("Expected %d lines in digest notice, got: %s", want+1, digest.Notice)
+	}
+
+	// ongoing issues are not repeated
+	round(time.Minute, down("a"), down("b"), down("c"))
+	round(time.Minute, down("a"), down("b"), down("c"))
+	if got, want := len(sender.sent), 1; got != want {
+		t.Fatalf("Expected %d message for ongoing issues, got %d", want, got)
+	}
+
+	// a new issue is rate limited until a token is available
+	round(time.Minute, down("a"), down("b"), down("c"), down("d"))
+	round(time.Minute, down("a"), down("b"), down("c"), down("d"))
+	if got, want := len(sender.sent), 1; got != want {
+		t.Fatalf("Expected %d message while rate limited, got %d", want, got)
+	}
+	round(7*time.Minute, down("a"), down("b"), down("c"), down("d"))
+	if got, want := len(sender.sent), 2; got != want {
+		t.Fatalf("Expected %d messages after rate limit, got %d", want, got)
+	}
+	if got, want := sender.sent[1].Title, "Checkup: 1 down"; got != want {
+		t.Errorf("Expected digest title '%s', got '%s'", want, got)
+	}
+
+	// a summary is sent for checks that stay down
+	round(time.Hour, down("a"), down("b"), up("c"), down("d"))
+	if got, want := len(sender.sent), 3; got != want {
+		t.Fatalf("Expected %d messages after summary interval, got %d", want, got)
+	}
+	if got, want := sender.sent[2].Title, "Checkup: 3 still down"; got != want {
+		t.Errorf("Expected summary title '%s', got '%s'", want, got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	config := []byte(`{"type":"digest","window":60000000000,"notifier":{"type":"fake"}}`)
+	sender := new(fakeSender)
+	n, err := New(config, func(typeName string, config json.RawMessage) (Sender, error) {
+		if typeName != "fake" {
+			t.Errorf("Expected notifier type 'fake', got '%s'", typeName)
+		}
+		return sender, nil
+	})
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := n.Window, time.Minute; got != want {
+		t.Errorf("Expected Window=%s, got %s", want, got)
+	}
+	if n.Sender != sender {
+		t.Errorf("Expected Sender to be the decoded notifier")
+	}
+
+	b, err := json.Marshal(n)
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := string(b), `{"window":60000000000,"notifier":{"type":"fake"}}`; got != want {
+		t.Errorf("Expected JSON '%s', got '%s'", want, got)
+	}
+}
@@ -66,6 +66,13 @@ func (s Notifier) Send(result types.Result) error {
 		Value:  result.Endpoint,
 		Inline: true,
 	})
+	if len(result.Tags) > 0 {
+		embed.AddField(&Field{
+			Name:   "Tags",
+			Value:  types.FormatTags(result.Tags),
+			Inline: true,
+		})
+	}
 	attach.AddEmbed(embed)
 	attach.Avatar = "https://placekitten.com/400/400"
 
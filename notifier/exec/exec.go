@@ -0,0 +1,189 @@
+package exec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/checkup/internal/command"
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "exec"
+
+// Notifier implements a Notifier by running programs with os.Exec.
+//
+// Issues are written to the program's stdin as JSON and described
+// in the CHECKUP_TITLE, CHECKUP_STATUS, CHECKUP_ENDPOINT and
+// CHECKUP_NOTICE environment variables. Programs run
+// concurrently, and Notify waits for them so that they aren't
+// cut off when checkup exits, but never longer than MaxWait.
+// Failures are logged rather than returned.
+type Notifier struct {
+	// Command is the main program entrypoint.
+	Command string `json:"command"`
+
+	// Arguments are individual program parameters.
+	Arguments []string `json:"arguments,omitempty"`
+
+	// PerRound runs the command once per round with all
+	// issues, rather than once for each issue. Stdin then
+	// holds a JSON list of results, CHECKUP_TITLE and
+	// CHECKUP_ENDPOINT are comma-separated, CHECKUP_STATUS
+	// is the worst status and CHECKUP_NOTICE holds one
+	// notice per line.
+	PerRound bool `json:"per_round,omitempty"`
+
+	// Timeout is the maximum time the command may run
+	// before it is killed, along with the processes it
+	// started. Default is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// MaxConcurrency is the maximum number of commands to
+	// run at once. Default is DefaultMaxConcurrency.
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+
+	// MaxWait is the maximum time Notify waits for all
+	// commands of a round. Commands that haven't started by
+	// then are skipped, and those still running are killed.
+	// Default is DefaultMaxWait.
+	MaxWait time.Duration `json:"max_wait,omitempty"`
+}
+
+// DefaultMaxConcurrency is the number of commands run at once
+// if MaxConcurrency is not set.
+const DefaultMaxConcurrency = 4
+
+// DefaultMaxWait is how long Notify waits for the commands of a
+// round if MaxWait is not set.
+const DefaultMaxWait = 30 * time.Second
+
+// maxOutputSize is how much of the output of a failed command
+// is logged.
+const maxOutputSize = 64 << 10
+
+// New creates a new Notifier instance based on json config
+func New(config json.RawMessage) (Notifier, error) {
+	var notifier Notifier
+	err := json.Unmarshal(config, &notifier)
+	if err == nil && notifier.Command == "" {
+		err = fmt.Errorf("exec: no command configured")
+	}
+	return notifier, err
+}
+
+// Type returns the notifier package name
+func (Notifier) Type() string {
+	return Type
+}
+
+// Notify implements notifier interface. It runs the command
+// and waits for the runs to finish, for up to n.MaxWait.
+func (n Notifier) Notify(results []types.Result) error {
+	issues := []types.Result{}
+	for _, result := range results {
+		if !result.Healthy {
+			issues = append(issues, result)
+		}
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+
+	maxWait := n.MaxWait
+	if maxWait <= 0 {
+		maxWait = DefaultMaxWait
+	}
+	deadline := time.Now().Add(maxWait)
+
+	if n.PerRound {
+		stdin, err := json.Marshal(issues)
+		if err != nil {
+			return err
+		}
+		n.run(stdin, environ(issues), deadline)
+		return nil
+	}
+
+	maxConcurrency := n.MaxConcurrency
+	if maxConcurrency < 1 {
+		maxConcurrency = DefaultMaxConcurrency
+	}
+	sem := make(chan struct{}, maxConcurrency)
+	expired := time.NewTimer(maxWait)
+	defer expired.Stop()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for i, issue := range issues {
+		stdin, err := json.Marshal(issue)
+		if err != nil {
+			return err
+		}
+		env := environ([]types.Result{issue})
+		select {
+		case sem <- struct{}{}:
+		case <-expired.C:
+			log.Printf("ERROR running exec notifier %s: skipped %d issues after waiting %s", n.Command, len(issues)-i, maxWait)
+			return nil
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			n.run(stdin, env, deadline)
+		}()
+	}
+	return nil
+}
+
+// run executes the command with stdin and the extra environment
+// variables in env, logging any failure. The command is killed
+// after n.Timeout, or at deadline if that is sooner.
+func (n Notifier) run(stdin []byte, env []string, deadline time.Time) {
+	timeout := n.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	if remaining := time.Until(deadline); remaining < timeout {
+		timeout = remaining
+	}
+
+	// #nosec G204
+	cmd := exec.Command(n.Command, n.Arguments...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Env = append(os.Environ(), env...)
+	output := &command.Buffer{Max: maxOutputSize}
+	if err := command.Run(cmd, output, output, timeout); err != nil {
+		log.Printf("ERROR running exec notifier %s: %s\nOutput: %s", n.Command, err, strings.TrimSpace(string(output.Bytes())))
+	}
+}
+
+// environ returns the environment variables describing results.
+func environ(results []types.Result) []string {
+	status := types.StatusUnknown
+	var titles, endpoints, notices []string
+	for _, result := range results {
+		if result.Status().PriorityOver(status) {
+			status = result.Status()
+		}
+		titles = append(titles, result.Title)
+		endpoints = append(endpoints, result.Endpoint)
+		notices = append(notices, result.Notice)
+	}
+	return []string{
+		"CHECKUP_TITLE=" + strings.Join(titles, ","),
+		"CHECKUP_STATUS=" + string(status),
+		"CHECKUP_ENDPOINT=" + strings.Join(endpoints, ","),
+		"CHECKUP_NOTICE=" + strings.Join(notices, "\n"),
+	}
+}
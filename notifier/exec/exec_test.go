@@ -0,0 +1,117 @@
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+func TestNotifier(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup-exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	results := []types.Result{
+		{Title: "A", Endpoint: "a.example.com", Down: true, Notice: "connection refused"},
+		{Title: "B", Endpoint: "b.example.com", Healthy: true},
+		{Title: "C", Endpoint: "c.example.com", Degraded: true},
+	}
+
+	// outputs returns the output of the scripts, which have
+	// all finished once Notify returns
+	outputs := func(prefix string) []string {
+		files, err := filepath.Glob(filepath.Join(dir, prefix+".*[0-9]"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var outputs []string
+		for _, file := range files {
+			b, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputs = append(outputs, string(b))
+		}
+		return outputs
+	}
+
+	// once per issue
+	{
+		n := Notifier{Command: "testdata/notify.sh", Arguments: []string{filepath.Join(dir, "each")}}
+		if err := n.Notify(results); err != nil {
+			t.Fatalf("Didn't expect an error: %v", err)
+		}
+		outputs := outputs("each")
+		if got, want := len(outputs), 2; got != want {
+			t.Fatalf("Expected %d runs, got %d", want, got)
+		}
+		all := strings.Join(outputs, "")
+		for _, want := range []string{
+			"A|down|a.example.com|connection refused\n",
+			"C|degraded|c.example.com|\n",
+			`"endpoint":"a.example.com"`,
+		} {
+			if !strings.Contains(all, want) {
+				t.Errorf("Expected output to contain '%s', got: %s", want, all)
+			}
+		}
+		if strings.Contains(all, "b.example.com") {
+			t.Errorf("Expected healthy result to be skipped, got: %s", all)
+		}
+	}
+
+	// one at a time
+	{
+		n := Notifier{Command: "testdata/notify.sh", Arguments: []string{filepath.Join(dir, "serial")}, MaxConcurrency: 1}
+		if err := n.Notify(results); err != nil {
+			t.Fatalf("Didn't expect an error: %v", err)
+		}
+		if got, want := len(outputs("serial")), 2; got != want {
+			t.Fatalf("Expected %d runs, got %d", want, got)
+		}
+	}
+
+	// once per round
+	{
+		n := Notifier{Command: "testdata/notify.sh", Arguments: []string{filepath.Join(dir, "round")}, PerRound: true}
+		if err := n.Notify(results); err != nil {
+			t.Fatalf("Didn't expect an error: %v", err)
+		}
+		outputs := outputs("round")
+		if got, want := len(outputs), 1; got != want {
+			t.Fatalf("Expected %d run, got %d", want, got)
+		}
+		if want := "A,C|down|a.example.com,c.example.com|"; !strings.HasPrefix(outputs[0], want) {
+			t.Errorf("Expected output to start with '%s', got: %s", want, outputs[0])
+		}
+		if want := `[{"title":"A"`; !strings.Contains(outputs[0], want) {
+			t.Errorf("Expected output to contain '%s', got: %s", want, outputs[0])
+		}
+	}
+
+	// neither descendants holding the output open nor slow
+	// commands hold up Notify for long
+	for i, n := range []Notifier{
+		{Command: "/bin/sh", Arguments: []string{"-c", "setsid sleep 5 & sleep 5"}, Timeout: 100 * time.Millisecond},
+		{Command: "/bin/sh", Arguments: []string{"-c", "sleep 5"}, MaxConcurrency: 1, MaxWait: 200 * time.Millisecond},
+	} {
+		if _, err := osexec.LookPath("setsid"); err != nil && i == 0 {
+			continue
+		}
+		start := time.Now()
+		if err := n.Notify(results); err != nil {
+			t.Fatalf("Test %d: Didn't expect an error: %v", i, err)
+		}
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Errorf("Test %d: Expected Notify to return soon, took %s", i, elapsed)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+#!/bin/bash
+out="$1.$$"
+{
+  echo "$CHECKUP_TITLE|$CHECKUP_STATUS|$CHECKUP_ENDPOINT|$CHECKUP_NOTICE"
+  cat
+} > "$out.tmp"
+mv "$out.tmp" "$out"
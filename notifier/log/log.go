@@ -0,0 +1,70 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "log"
+
+// Notifier writes every result as one line of JSON to a
+// file or to stdout, for consumption by log pipelines.
+type Notifier struct {
+	// File is the path of the file to append results to.
+	// If empty or "-", results are written to stdout.
+	File string `json:"file,omitempty"`
+}
+
+// Entry is the JSON line written for each result.
+type Entry struct {
+	types.Result
+
+	// Status is the overall status of the result.
+	Status types.StatusText `json:"status"`
+}
+
+// mu serializes writes so that lines from concurrent
+// calls to Notify are not interleaved.
+var mu sync.Mutex
+
+// New creates a new Notifier instance based on json config
+func New(config json.RawMessage) (Notifier, error) {
+	var notifier Notifier
+	err := json.Unmarshal(config, &notifier)
+	return notifier, err
+}
+
+// Type returns the notifier package name
+func (Notifier) Type() string {
+	return Type
+}
+
+// Notify implements notifier interface
+func (l Notifier) Notify(results []types.Result) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var w io.Writer = os.Stdout
+	if l.File != "" && l.File != "-" {
+		f, err := os.OpenFile(l.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("log: error opening file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, result := range results {
+		if err := encoder.Encode(Entry{Result: result, Status: result.Status()}); err != nil {
+			return fmt.Errorf("log: error writing result: %w", err)
+		}
+	}
+	return nil
+}
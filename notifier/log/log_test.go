@@ -0,0 +1,60 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+func TestNotifier(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkup-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	n := Notifier{File: filepath.Join(dir, "results.log")}
+	results := []types.Result{
+		{Title: "Example", Endpoint: "example.com", Healthy: true},
+		{Title: "API", Endpoint: "api.example.com", Degraded: true, Notice: "slow"},
+	}
+	for i := 0; i < 2; i++ {
+		if err := n.Notify(results); err != nil {
+			t.Fatalf("Didn't expect an error: %v", err)
+		}
+	}
+
+	f, err := os.Open(n.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Expected a JSON line, got '%s': %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if got, want := len(entries), 4; got != want {
+		t.Fatalf("Expected %d lines, got %d", want, got)
+	}
+	if got, want := entries[3].Title, "API"; got != want {
+		t.Errorf("Expected Title='%s', got '%s'", want, got)
+	}
+	if got, want := entries[3].Status, types.StatusDegraded; got != want {
+		t.Errorf("Expected Status='%s', got '%s'", want, got)
+	}
+	if got, want := entries[3].Notice, "slow"; got != want {
+		t.Errorf("Expected Notice='%s', got '%s'", want, got)
+	}
+}
@@ -54,6 +54,9 @@ func (s Notifier) Send(result types.Result) error {
 	attach := slack.Attachment{}
 	attach.AddField(slack.Field{Title: result.Title, Value: result.Endpoint})
 	attach.AddField(slack.Field{Title: "Status", Value: strings.ToUpper(fmt.Sprint(result.Status()))})
+	if len(result.Tags) > 0 {
+		attach.AddField(slack.Field{Title: "Tags", Value: types.FormatTags(result.Tags)})
+	}
 	attach.Color = &color
 	payload := slack.Payload{
 		Text:        result.Title,
@@ -0,0 +1,223 @@
+package syslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+// Type should match the package name
+const Type = "syslog"
+
+// Notifier sends every result to a syslog server as an
+// RFC 5424 message, with the severity mapped from the
+// result's status.
+type Notifier struct {
+	// Network is one of "udp", "tcp", "unix" or "unixgram".
+	// Messages sent over stream sockets are framed with
+	// octet counting (RFC 6587). Default is "udp".
+	Network string `json:"network,omitempty"`
+
+	// Address is the address of the syslog server, e.g.
+	// "localhost:514" or "/dev/log".
+	Address string `json:"address"`
+
+	// Facility is the syslog facility name, e.g. "daemon"
+	// or "local0". Default is "daemon".
+	Facility string `json:"facility,omitempty"`
+
+	// AppName is the APP-NAME of the messages. Default
+	// is "checkup".
+	AppName string `json:"app_name,omitempty"`
+
+	// Hostname is the HOSTNAME of the messages. Default
+	// is the name of the host checkup runs on.
+	Hostname string `json:"hostname,omitempty"`
+
+	// Timeout is the maximum time to wait for the
+	// connection to the server and for writes. Default
+	// is 10 seconds.
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// facilities maps facility names to their codes.
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3,
+	"auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// Severities of the messages sent for each status.
+const (
+	SeverityError   = 3
+	SeverityWarning = 4
+	SeverityNotice  = 5
+	SeverityInfo    = 6
+)
+
+// New creates a new Notifier instance based on json config
+func New(config json.RawMessage) (Notifier, error) {
+	var notifier Notifier
+	err := json.Unmarshal(config, &notifier)
+	if err != nil {
+		return notifier, err
+	}
+	if notifier.Network == "" {
+		notifier.Network = "udp"
+	}
+	if notifier.Facility == "" {
+		notifier.Facility = "daemon"
+	}
+	if _, ok := facilities[notifier.Facility]; !ok {
+		return notifier, fmt.Errorf("syslog: unknown facility: %s", notifier.Facility)
+	}
+	if notifier.AppName == "" {
+		notifier.AppName = "checkup"
+	}
+	return notifier, nil
+}
+
+// Type returns the notifier package name
+func (Notifier) Type() string {
+	return Type
+}
+
+// Notify implements notifier interface
+func (s Notifier) Notify(results []types.Result) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	network := s.Network
+	if network == "" {
+		network = "udp"
+	}
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	conn, err := net.DialTimeout(network, s.Address, timeout)
+	if err != nil {
+		return fmt.Errorf("syslog: error connecting: %w", err)
+	}
+	defer conn.Close()
+
+	stream := network == "tcp" || network == "unix"
+	for _, result := range results {
+		msg := s.format(result)
+		if stream {
+			msg = fmt.Sprintf("%d %s", len(msg), msg)
+		}
+		if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+			return err
+		}
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			return fmt.Errorf("syslog: error writing: %w", err)
+		}
+	}
+	return nil
+}
+
+// format renders result as an RFC 5424 message.
+func (s Notifier) format(result types.Result) string {
+	facility, ok := facilities[s.Facility]
+	if !ok {
+		facility = facilities["daemon"]
+	}
+	appName := s.AppName
+	if appName == "" {
+		appName = "checkup"
+	}
+	hostname := s.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+
+	ts := time.Now().UTC()
+	if result.Timestamp != 0 {
+		ts = time.Unix(0, result.Timestamp).UTC()
+	}
+
+	status := result.Status()
+	data := fmt.Sprintf(`[checkup@32473 title="%s" endpoint="%s" status="%s"]`,
+		escape(result.Title), escape(result.Endpoint), status)
+	if len(result.Tags) > 0 {
+		keys := make([]string, 0, len(result.Tags))
+		for k := range result.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		data += "[tags@32473"
+		for _, k := range keys {
+			data += fmt.Sprintf(` %s="%s"`, paramName(k), escape(result.Tags[k]))
+		}
+		data += "]"
+	}
+
+	msg := fmt.Sprintf("%s - %s", result.Title, status)
+	if result.Notice != "" {
+		msg += ": " + result.Notice
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d result %s %s",
+		facility*8+severity(status), ts.Format(time.RFC3339Nano),
+		header(hostname), header(appName), os.Getpid(), data, msg)
+}
+
+// severity returns the syslog severity for status.
+func severity(status types.StatusText) int {
+	switch status {
+	case types.StatusDown:
+		return SeverityError
+	case types.StatusDegraded:
+		return SeverityWarning
+	case types.StatusHealthy:
+		return SeverityInfo
+	}
+	return SeverityNotice
+}
+
+// header returns s as a valid header field, which must be
+// printable ASCII without spaces, or "-" if empty.
+func header(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, s)
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// paramName returns s as a valid structured data parameter
+// name, which must be at most 32 printable ASCII characters
+// other than '=', ' ', ']' and '"'.
+func paramName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > 32 {
+		s = s[:32]
+	}
+	return s
+}
+
+// escape escapes s for use as a structured data parameter value.
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(s)
+}
@@ -0,0 +1,109 @@
+package syslog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/checkup/types"
+)
+
+var results = []types.Result{
+	{Title: "Example", Endpoint: "example.com", Timestamp: 1e18, Healthy: true, Tags: map[string]string{"team": "ops", "env": "prod"}},
+	{Title: `Broken "API"`, Endpoint: "api.example.com", Timestamp: 1e18, Down: true, Notice: "connection refused"},
+}
+
+func TestNotifierUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	n, err := New([]byte(`{"address":"` + conn.LocalAddr().String() + `","hostname":"checker","facility":"local0"}`))
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if err := n.Notify(results); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+
+	var msgs []string
+	buf := make([]byte, 2048)
+	for range results {
+		l, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, string(buf[:l]))
+	}
+
+	want := `<134>1 2001-09-09T01:46:40Z checker checkup `
+	if !strings.HasPrefix(msgs[0], want) {
+		t.Errorf("Expected message to start with '%s', got '%s'", want, msgs[0])
+	}
+	want = ` result [checkup@32473 title="Example" endpoint="example.com" status="healthy"][tags@32473 env="prod" team="ops"] Example - healthy`
+	if !strings.HasSuffix(msgs[0], want) {
+		t.Errorf("Expected message to end with '%s', got '%s'", want, msgs[0])
+	}
+
+	want = `<131>1 `
+	if !strings.HasPrefix(msgs[1], want) {
+		t.Errorf("Expected message to start with '%s', got '%s'", want, msgs[1])
+	}
+	want = `[checkup@32473 title="Broken \"API\"" endpoint="api.example.com" status="down"] Broken "API" - down: connection refused`
+	if !strings.HasSuffix(msgs[1], want) {
+		t.Errorf("Expected message to end with '%s', got '%s'", want, msgs[1])
+	}
+}
+
+func TestNotifierTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan []string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		defer conn.Close()
+		var msgs []string
+		r := bufio.NewReader(conn)
+		for range results {
+			var length int
+			if _, err := fmt.Fscanf(r, "%d ", &length); err != nil {
+				break
+			}
+			msg := make([]byte, length)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				break
+			}
+			msgs = append(msgs, string(msg))
+		}
+		received <- msgs
+	}()
+
+	n, err := New([]byte(`{"network":"tcp","address":"` + ln.Addr().String() + `"}`))
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if err := n.Notify(results); err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+
+	msgs := <-received
+	if got, want := len(msgs), len(results); got != want {
+		t.Fatalf("Expected %d messages, got %d: %v", want, got, msgs)
+	}
+	if want := `<27>1 `; !strings.HasPrefix(msgs[1], want) {
+		t.Errorf("Expected message to start with '%s', got '%s'", want, msgs[1])
+	}
+}
@@ -122,6 +122,10 @@ checkup.groupedResults = {};
 // guaranteed until all results are loaded
 checkup.orderedResults = [];
 
+// Stores the aggregate results of groups, which have members
+// instead of an endpoint, keyed by group name
+checkup.components = {};
+
 // Stores the charts (keyed by endpoint) and all their data/info/elements
 checkup.charts = {};
 
@@ -201,8 +205,20 @@ checkup.loadScript = function(url, callback) {
 	head.appendChild(script);
 };
 
-// computeStats computes basic stats about a result.
+// status returns the name of the status of a result.
+checkup.status = function(result) {
+	if (result.healthy) return "healthy";
+	if (result.degraded) return "degraded";
+	if (result.down) return "down";
+	return "unknown";
+};
+
+// computeStats computes basic stats about a result. Aggregate
+// results of groups have no times, so their stats are zero.
 checkup.computeStats = function(result) {
+	if (!result.times || result.times.length == 0)
+		return { total: 0, average: 0, median: 0, min: 0, max: 0 };
+
 	function median(values) {
 		values.sort(function(a, b) { return a.rtt - b.rtt; });
 		var half = Math.floor(values.length / 2);
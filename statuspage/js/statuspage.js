@@ -28,6 +28,7 @@ document.addEventListener('DOMContentLoaded', function() {
 	checkup.dom.checkcount = document.getElementById("info-checkcount");
 	checkup.dom.lastcheck = document.getElementById("info-lastcheck");
 	checkup.dom.timeline = document.getElementById("timeline");
+	checkup.dom.components = document.getElementById("components");
 	// Immediately begin downloading check files, and keep page updated
 	checkup.storage.getChecksWithin(checkup.config.timeframe, processNewCheckFile, allCheckFilesLoaded);
 
@@ -96,6 +97,16 @@ function processNewCheckFile(json, filename) {
 		// Save stats with the result so we don't have to recompute them later
 		result.stats = checkup.computeStats(result);
 
+		// Aggregate results of groups are rendered as components
+		// rather than charts, since they have no endpoint or times
+		if (result.members) {
+			if (!checkup.components[result.title])
+				checkup.components[result.title] = [result];
+			else
+				checkup.components[result.title].push(result);
+			return;
+		}
+
 		var chart = process(result);
 		checkup.charts[result.endpoint] = chart;
 		checkup.charts[result.endpoint].endpoint = result.endpoint;
@@ -272,9 +283,66 @@ function allCheckFilesLoaded(numChecksLoaded, numResultsLoaded) {
 		document.getElementById("big-gap").style.display = 'none';
 	}
 
+	renderComponents();
+
 	makeGraphs(); // must render graphs again after we've filled in the event series
 }
 
+// renderComponents renders the latest status of each group and
+// of the checks that make it up.
+function renderComponents() {
+	var latest = function(results) {
+		return results.reduce(function(a, b) { return b.timestamp > a.timestamp ? b : a; });
+	};
+
+	var members = {}; // latest result of each check, keyed by title
+	for (var endpoint in checkup.results) {
+		var result = latest(checkup.results[endpoint]);
+		members[result.title] = result;
+	}
+
+	var names = Object.keys(checkup.components).sort();
+	checkup.dom.components.innerHTML = "";
+	checkup.dom.components.style.display = names.length > 0 ? "flex" : "none";
+
+	names.forEach(function(name) {
+		var result = latest(checkup.components[name]);
+		var status = checkup.status(result);
+
+		var el = document.createElement("div");
+		el.className = "component "+(checkup.color[status] || "gray");
+
+		var title = document.createElement("div");
+		title.className = "component-title";
+		title.appendChild(document.createTextNode(result.title));
+		var statusEl = document.createElement("span");
+		statusEl.className = "component-status";
+		statusEl.appendChild(document.createTextNode(status));
+		title.appendChild(statusEl);
+		el.appendChild(title);
+
+		if (result.notice) {
+			var notice = document.createElement("div");
+			notice.className = "component-notice";
+			notice.appendChild(document.createTextNode(result.notice));
+			el.appendChild(notice);
+		}
+
+		var list = document.createElement("div");
+		list.className = "component-members";
+		result.members.forEach(function(memberTitle) {
+			var member = members[memberTitle];
+			var memberEl = document.createElement("span");
+			memberEl.className = "member "+(member ? checkup.color[checkup.status(member)] || "gray" : "gray");
+			memberEl.appendChild(document.createTextNode(memberTitle));
+			list.appendChild(memberEl);
+		});
+		el.appendChild(list);
+
+		checkup.dom.components.appendChild(el);
+	});
+}
+
 function makeGraphs() {
 	checkup.dom.timeframe.innerHTML = checkup.formatDuration(checkup.config.timeframe);
 	checkup.dom.checkcount.innerHTML = checkup.checks.length;
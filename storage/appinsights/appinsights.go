@@ -82,6 +82,10 @@ func New(config json.RawMessage) (Storage, error) {
 	return storage, err
 }
 
+// TagPrefix is prepended to the keys of the tags of results
+// in the properties of telemetry items.
+const TagPrefix = "tag."
+
 // Type returns the logger package name
 func (Storage) Type() string {
 	return Type
@@ -142,6 +146,11 @@ func (c Storage) send(conclude types.Result) {
 		availability.GetMeasurements()[k] = float64(conclude.Times[i].RTT)
 	}
 	availability.GetProperties()["ThresholdRTT"] = conclude.ThresholdRTT.String()
+	// prefix the tags of results so they can't overwrite
+	// the properties above
+	for k, v := range conclude.Tags {
+		availability.GetProperties()[TagPrefix+k] = v
+	}
 
 	// Submit the telemetry
 	c.client.Track(availability)
@@ -0,0 +1,34 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Metric is a measurement reported by a check, such as
+// the performance data of a Nagios plugin.
+type Metric struct {
+	// Label is the name of the metric.
+	Label string `json:"label"`
+
+	// Value is the measured value, in Unit.
+	Value float64 `json:"value"`
+
+	// Unit is the unit of measurement, such as "s",
+	// "%", "B" or "c" (a counter), if any.
+	Unit string `json:"unit,omitempty"`
+
+	// Warn and Crit are the warning and critical
+	// threshold ranges, if any, such as "10:20".
+	Warn string `json:"warn,omitempty"`
+	Crit string `json:"crit,omitempty"`
+
+	// Min and Max are the bounds of Value, if known.
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// String returns a human-readable rendering of m.
+func (m Metric) String() string {
+	return fmt.Sprintf("%s=%s%s", m.Label, strconv.FormatFloat(m.Value, 'f', -1, 64), m.Unit)
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
@@ -18,6 +19,11 @@ type Result struct {
 	// of what was checked.
 	Endpoint string `json:"endpoint,omitempty"`
 
+	// Tags are the labels of the checker that produced this
+	// result, such as the team, environment or service. They
+	// can be used to group and filter results.
+	Tags map[string]string `json:"tags,omitempty"`
+
 	// Timestamp is when the check occurred; UTC UnixNano format.
 	Timestamp int64 `json:"timestamp,omitempty"`
 
@@ -29,8 +35,9 @@ type Result struct {
 	ThresholdRTT time.Duration `json:"threshold,omitempty"`
 
 	// Healthy, Degraded, and Down contain the ultimate conclusion
-	// about the endpoint. Exactly one of these should be true;
-	// any more or less is a bug.
+	// about the endpoint. At most one of these should be true;
+	// any more is a bug. If none is, the status is unknown, as
+	// when a check reports that it couldn't determine it.
 	Healthy  bool `json:"healthy,omitempty"`
 	Degraded bool `json:"degraded,omitempty"`
 	Down     bool `json:"down,omitempty"`
@@ -40,9 +47,25 @@ type Result struct {
 	// For example, that the median RTT is above the threshold.
 	Notice string `json:"notice,omitempty"`
 
+	// Metrics are measurements reported by the check, if any.
+	Metrics []Metric `json:"metrics,omitempty"`
+
 	// Message is an optional message to show on the status page.
 	// For example, what you're doing to fix a problem.
 	Message string `json:"message,omitempty"`
+
+	// Unreachable is true if the endpoint is unhealthy because
+	// a check it depends on is down. Notifications are not
+	// sent for unreachable results.
+	Unreachable bool `json:"unreachable,omitempty"`
+
+	// Group is the name of the first group this result
+	// belongs to, if any.
+	Group string `json:"group,omitempty"`
+
+	// Members is the list of titles of the results that make
+	// up this result, if it is the aggregate result of a group.
+	Members []string `json:"members,omitempty"`
 }
 
 func NewResult() Result {
@@ -54,6 +77,9 @@ func NewResult() Result {
 // ComputeStats computes basic statistics about r.
 func (r Result) ComputeStats() Stats {
 	var s Stats
+	if len(r.Times) == 0 {
+		return s
+	}
 
 	for _, a := range r.Times {
 		s.Total += a.RTT
@@ -89,12 +115,18 @@ func DisableColor() {
 func (r Result) String() string {
 	stats := r.ComputeStats()
 	s := fmt.Sprintf("== %s - %s\n", r.Title, r.Endpoint)
+	if len(r.Tags) > 0 {
+		s += fmt.Sprintf("       Tags: %s\n", FormatTags(r.Tags))
+	}
 	s += fmt.Sprintf("  Threshold: %s\n", r.ThresholdRTT)
 	s += fmt.Sprintf("        Max: %s\n", stats.Max)
 	s += fmt.Sprintf("        Min: %s\n", stats.Min)
 	s += fmt.Sprintf("     Median: %s\n", stats.Median)
 	s += fmt.Sprintf("       Mean: %s\n", stats.Mean)
 	s += fmt.Sprintf("        All: %v\n", r.Times)
+	if len(r.Metrics) > 0 {
+		s += fmt.Sprintf("    Metrics: %v\n", r.Metrics)
+	}
 	statusLine := fmt.Sprintf(" Assessment: %v\n", r.Status())
 	switch r.Status() {
 	case StatusHealthy:
@@ -108,6 +140,41 @@ func (r Result) String() string {
 	return s
 }
 
+// HasTags returns whether r has all of the given tags
+// with the same values.
+func (r Result) HasTags(tags map[string]string) bool {
+	for k, v := range tags {
+		if value, ok := r.Tags[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatTags renders tags as a sorted, comma-separated
+// list of key=value pairs.
+func FormatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// ParseTags parses a list of key=value pairs into a map.
+func ParseTags(pairs []string) (map[string]string, error) {
+	tags := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid tag '%s' (must be key=value)", pair)
+		}
+		tags[parts[0]] = parts[1]
+	}
+	return tags, nil
+}
+
 // Status returns a text representation of the overall status
 // indicated in r.
 func (r Result) Status() StatusText {
@@ -0,0 +1,34 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	tags, err := ParseTags([]string{"env=prod", "team=ops=core"})
+	if err != nil {
+		t.Fatalf("Didn't expect an error: %v", err)
+	}
+	if got, want := FormatTags(tags), "env=prod, team=ops=core"; got != want {
+		t.Errorf("Expected tags '%s', got '%s'", want, got)
+	}
+	if _, err := ParseTags([]string{"env"}); err == nil {
+		t.Error("Expected an error for a tag without a value, didn't get one")
+	}
+
+	r := Result{Tags: map[string]string{"env": "prod", "team": "ops=core", "service": "api"}}
+	for i, test := range []struct {
+		tags     map[string]string
+		expected bool
+	}{
+		{nil, true},
+		{map[string]string{"env": "prod"}, true},
+		{tags, true},
+		{map[string]string{"env": "staging"}, false},
+		{map[string]string{"region": "eu"}, false},
+	} {
+		if got := r.HasTags(test.tags); got != test.expected {
+			t.Errorf("Test %d: Expected HasTags(%v)=%v, got %v", i, test.tags, test.expected, got)
+		}
+	}
+}